@@ -0,0 +1,285 @@
+package got
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/user"
+	"github.com/SlothNinja/gt/engine"
+	"github.com/gin-gonic/gin"
+	"go.chromium.org/gae/service/taskqueue"
+	"golang.org/x/net/context"
+)
+
+// Bot strategies.
+const (
+	randomBot     = "random"
+	greedyBot     = "greedy"
+	expectimaxBot = "expectimax"
+	mctsBot       = "mcts"
+)
+
+// defaultMCTSBudget is the time spent searching each move by bots using Monte Carlo tree search.
+const defaultMCTSBudget = time.Second
+
+var botStrategies = map[string]engine.Strategy{
+	randomBot:     engine.RandomStrategy{},
+	greedyBot:     engine.GreedyStrategy{},
+	expectimaxBot: engine.ExpectimaxStrategy{},
+	mctsBot:       engine.MCTSStrategy{Budget: defaultMCTSBudget},
+}
+
+var botNames = map[string]string{
+	randomBot:     "Random",
+	greedyBot:     "Greedy",
+	expectimaxBot: "Expectimax",
+	mctsBot:       "MCTS",
+}
+
+// SetMCTSBudget limits the search of each move by bots using Monte Carlo tree search to the number of
+// iterations or the duration, whichever is reached first.  A limit of zero is ignored.
+// It must be called before the bots are put to use.
+func SetMCTSBudget(iterations int, d time.Duration) {
+	botStrategies[mctsBot] = engine.MCTSStrategy{Iterations: iterations, Budget: d}
+}
+
+// Bot levels selectable when creating a game.
+const (
+	easyLevel   = "easy"
+	mediumLevel = "medium"
+	hardLevel   = "hard"
+	expertLevel = "expert"
+)
+
+// botLevels maps each bot level to the strategy of bots playing at that level.
+var botLevels = map[string]string{
+	easyLevel:   randomBot,
+	mediumLevel: greedyBot,
+	hardLevel:   expectimaxBot,
+	expertLevel: mctsBot,
+}
+
+var botLevelNames = map[string]string{
+	easyLevel:   "Easy",
+	mediumLevel: "Medium",
+	hardLevel:   "Hard",
+	expertLevel: "Expert",
+}
+
+// botLevel returns the level of the game's bots, defaulting to easy.
+func (g *Game) botLevel() string {
+	if g.BotLevel == "" {
+		return easyLevel
+	}
+	return g.BotLevel
+}
+
+// IsBot indicates whether the player is controlled by the computer.
+func (p *Player) IsBot() bool {
+	return p.Bot != ""
+}
+
+// IsCurrentUser indicates whether the player is controlled by the current user.
+func (p *Player) IsCurrentUser(ctx context.Context) bool {
+	if p.IsBot() {
+		return false
+	}
+	return p.Player.IsCurrentUser(ctx)
+}
+
+func (p *Player) botName() string {
+	return fmt.Sprintf("%s Bot %d", botNames[p.Bot], p.ID()+1)
+}
+
+// NameFor returns the name of the player, naming computer controlled players by their strategy.
+func (g *Game) NameFor(p game.Playerer) string {
+	if player, ok := p.(*Player); ok && player.IsBot() {
+		return player.botName()
+	}
+	return g.Header.NameFor(p)
+}
+
+// NameByPID returns the name of the player having the player id.
+func (g *Game) NameByPID(pid int) string {
+	if p := g.PlayerByID(pid); p != nil && p.IsBot() {
+		return p.botName()
+	}
+	return g.Header.NameByPID(pid)
+}
+
+// hasBots indicates whether any player of the game is controlled by the computer.
+func (g *Game) hasBots() bool {
+	if g.Bots > 0 {
+		return true
+	}
+
+	for _, p := range g.Players() {
+		if p.IsBot() {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Game) validateBots() error {
+	switch {
+	case g.Bots < 0:
+		return fmt.Errorf("invalid number of bots: %d", g.Bots)
+	case g.Bots >= g.NumPlayers:
+		return fmt.Errorf("at least one seat must be left for a player, but %d of %d seats are bots", g.Bots, g.NumPlayers)
+	case botLevels[g.botLevel()] == "":
+		return fmt.Errorf("invalid bot level %q", g.BotLevel)
+	default:
+		return nil
+	}
+}
+
+func (g *Game) addBotPlayers() {
+	for i := 0; i < g.Bots; i++ {
+		p := createPlayer(g, nil)
+		p.Bot = botLevels[g.botLevel()]
+		g.Playerers = append(g.Playerers, p)
+	}
+}
+
+// playBots takes the turns of computer controlled players until it is a human player's turn or the game ends.
+// It returns the entities to save with the game, such as the contests of a game ended by a bot.
+func (g *Game) playBots(ctx context.Context) ([]interface{}, error) {
+	// Bots draw from their own stream, so their choices do not disturb the game's.
+	r := engine.NewRand(time.Now().UnixNano())
+	played := false
+	for g.Status == game.Running && g.Phase != gameOver {
+		cp := g.CurrentPlayer()
+		if cp == nil || !cp.IsBot() {
+			break
+		}
+
+		s := g.engineState()
+		ms := engine.LegalActions(s, cp.ID())
+		if len(ms) == 0 {
+			return nil, fmt.Errorf("%s has no legal move", g.NameFor(cp))
+		}
+
+		strategy, ok := botStrategies[cp.Bot]
+		if !ok {
+			return nil, fmt.Errorf("%s has unknown strategy %q", g.NameFor(cp), cp.Bot)
+		}
+
+		for _, a := range strategy.Choose(s, cp.ID(), ms, &r).Actions {
+			var err error
+			if a.Type == engine.FinishTurn {
+				err = g.finishTurn(ctx)
+			} else {
+				err = g.apply(ctx, a)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		played = true
+	}
+
+	switch {
+	case !played:
+		return nil, nil
+	case g.Phase == gameOver:
+		return g.finishGame(ctx), nil
+	}
+
+	if err := g.sendTurnNotificationsTo(ctx, g.CurrentPlayer()); err != nil {
+		log.Warningf(ctx, err.Error())
+	}
+	return nil, nil
+}
+
+// awaitsBot indicates whether the running game awaits the turn of a computer controlled player.
+func (g *Game) awaitsBot() bool {
+	cp := g.CurrentPlayer()
+	return g.Status == game.Running && g.Phase != gameOver && cp != nil && cp.IsBot()
+}
+
+// BotScheduler arranges for the turns of computer controlled players to be taken once a game awaiting them is saved,
+// so that the search of the bots does not delay the request saving the game.
+type BotScheduler interface {
+	Schedule(ctx context.Context, g *Game) error
+}
+
+// botQueue is the task queue in which the turns of bots are taken.
+const botQueue = "bots"
+
+// taskBotScheduler takes the turns of bots in a task of the App Engine task queue.
+type taskBotScheduler struct{}
+
+// Schedule adds a task taking the turns of the bots.  A task finding the turns already taken does nothing.
+func (taskBotScheduler) Schedule(ctx context.Context, g *Game) error {
+	return taskqueue.Add(ctx, botQueue, &taskqueue.Task{
+		Path: fmt.Sprintf("%s/tasks/bots/%d", g.Type.Prefix(), g.ID),
+	})
+}
+
+var botScheduler BotScheduler = taskBotScheduler{}
+
+// SetBotScheduler replaces the scheduler of the turns of bots.
+func SetBotScheduler(s BotScheduler) {
+	botScheduler = s
+}
+
+// scheduleBots schedules the turns of bots if the saved game awaits a bot.
+func (g *Game) scheduleBots(ctx context.Context) {
+	if !g.awaitsBot() {
+		return
+	}
+
+	if err := botScheduler.Schedule(ctx, g); err != nil {
+		log.Warningf(ctx, "unable to schedule the bots of game %d: %v", g.ID, err)
+	}
+}
+
+// botTurns takes the turns of the bots awaited by a game and saves the game.
+// It is requested by the bot task queue, and may also be requested by admins.
+// An error response causes the task to be retried, such as when a player saved the game first.
+func botTurns(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	if c.GetHeader("X-AppEngine-QueueName") != botQueue && !user.IsAdmin(ctx) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the task queue and admins may take the turns of bots."})
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("hid"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid game id"})
+		return
+	}
+
+	g := New(ctx)
+	g.ID = id
+	if err = dsGet(ctx, g); err != nil {
+		log.Errorf(ctx, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to get game"})
+		return
+	}
+
+	if !g.awaitsBot() {
+		c.JSON(http.StatusOK, gin.H{"version": apiVersion, "played": false})
+		return
+	}
+
+	es, err := g.playBots(ctx)
+	if err == nil {
+		err = g.save(ctx, es...)
+	}
+	if err != nil {
+		log.Errorf(ctx, "unable to take the turns of the bots of game %d: %v", g.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to take the turns of the bots"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"version": apiVersion, "played": true})
+}
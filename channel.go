@@ -0,0 +1,411 @@
+package got
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/user"
+	"github.com/gin-gonic/gin"
+	"go.chromium.org/gae/service/datastore"
+	"go.chromium.org/gae/service/mail"
+	"go.chromium.org/gae/service/urlfetch"
+	"golang.org/x/net/context"
+)
+
+// Notification channels provided by default.
+const (
+	emailChannel   = "email"
+	webhookChannel = "webhook"
+	chatChannel    = "chat"
+)
+
+// Events of which users are notified, besides gameEnded.
+const (
+	yourTurn     = "turn.yours"
+	turnReminder = "turn.reminder"
+	gamesDigest  = "games.digest"
+)
+
+var notificationEvents = []string{yourTurn, turnReminder, gamesDigest, gameEnded}
+
+// Notification is a message to a user about an event.
+type Notification struct {
+	Event    string
+	User     *user.User
+	Subject  string
+	HTMLBody string
+	// Text is a summary of the message in plain text, for channels such as chat.
+	Text    string
+	GameIDs []int64
+	// Address is the address of the user on the channel, such as the URL of a chat channel.
+	// Email is sent to the email of the user.
+	Address string
+
+	// mail, if set, sends the email in place of the email channel, for notifications formatted elsewhere.
+	mail func(ctx context.Context) error
+}
+
+// Channel sends notifications by a means such as email.
+type Channel interface {
+	Send(ctx context.Context, n *Notification) error
+}
+
+var channels = map[string]Channel{
+	emailChannel:   emailSender{},
+	webhookChannel: webhookSender{},
+	chatChannel:    chatSender{},
+}
+
+// RegisterChannel makes the channel available, by its name, to users choosing how they are notified.
+// It must be called before serving requests.
+func RegisterChannel(name string, c Channel) error {
+	if name == "" || strings.Contains(name, "=") {
+		return fmt.Errorf("invalid channel name %q", name)
+	}
+	if _, ok := channels[name]; ok {
+		return fmt.Errorf("channel %q is already registered", name)
+	}
+	channels[name] = c
+	return nil
+}
+
+func channelNames() []string {
+	names := make([]string, 0, len(channels))
+	for name := range channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// emailSender sends notifications as email, using the notifier.
+type emailSender struct{}
+
+func (emailSender) Send(ctx context.Context, n *Notification) error {
+	if n.mail != nil {
+		return n.mail(ctx)
+	}
+	return notifier.Notify(ctx, &mail.Message{
+		To:       []string{n.User.Email},
+		Sender:   notificationSender,
+		Subject:  n.Subject,
+		HTMLBody: n.HTMLBody,
+	})
+}
+
+// webhookSender posts notifications to a webhook of the user, identified by the address.
+// Deliveries are recorded and sent as those of game events.
+type webhookSender struct{}
+
+// webhookNotification is the JSON payload of a notification posted to a webhook.
+type webhookNotification struct {
+	Event   string  `json:"event"`
+	User    string  `json:"user"`
+	Subject string  `json:"subject"`
+	Body    string  `json:"body,omitempty"`
+	Text    string  `json:"text,omitempty"`
+	GameIDs []int64 `json:"gameIds,omitempty"`
+}
+
+func (webhookSender) Send(ctx context.Context, n *Notification) error {
+	id, err := strconv.ParseInt(n.Address, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid webhook %q", n.Address)
+	}
+
+	h := &webhook{ID: id, Parent: datastore.KeyForObj(ctx, n.User)}
+	if err = datastore.Get(ctx, h); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(webhookNotification{
+		Event:   n.Event,
+		User:    n.User.Name,
+		Subject: n.Subject,
+		Body:    n.HTMLBody,
+		Text:    n.Text,
+		GameIDs: n.GameIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	var gid int64
+	if len(n.GameIDs) == 1 {
+		gid = n.GameIDs[0]
+	}
+	return queueDeliveries(ctx, []*webhook{h}, gid, n.Event, payload)
+}
+
+// chatSender posts notifications to a chat service's incoming webhook, at the URL given by the address,
+// as JSON with the text of the message.
+type chatSender struct{}
+
+func (chatSender) Send(ctx context.Context, n *Notification) error {
+	text := n.Text
+	if text == "" {
+		text = n.Subject
+	}
+
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Transport: urlfetch.Get(ctx), Timeout: webhookTimeout}
+	resp, err := client.Post(n.Address, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("chat responded %s", resp.Status)
+	}
+	return nil
+}
+
+// notificationBody renders the notification template named name, such as "got/end_game_notification",
+// with data.  Without such a template, fallback, if any, is rendered instead.
+func notificationBody(ctx context.Context, name string, fallback *template.Template, data interface{}) (string, error) {
+	tmpl, ok := restful.TemplatesFrom(ctx)[name]
+	if !ok || tmpl == nil {
+		tmpl = fallback
+	}
+	if tmpl == nil {
+		return "", fmt.Errorf("missing template %q", name)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// notificationPrefs records the channels on which a user is notified of each event, and the addresses
+// of the user on channels other than email.  It is kept within the key of the user.
+// Without preferences, a user is notified of every event by email.
+type notificationPrefs struct {
+	Kind   string         `gae:"$kind,GOTNotificationPrefs"`
+	ID     string         `gae:"$id"`
+	Parent *datastore.Key `gae:"$parent"`
+	// Routes lists, as event=channel, the channels on which the user is notified of each event.
+	Routes []string `gae:",noindex"`
+	// Addresses lists, as channel=address, the address of the user on each channel.
+	Addresses []string `gae:",noindex"`
+}
+
+const notificationPrefsID = "prefs"
+
+// notificationPrefsOf returns the preferences of user u.  Preferences that cannot be retrieved are
+// replaced by the default, so that users are notified nonetheless.
+func notificationPrefsOf(ctx context.Context, u *user.User) *notificationPrefs {
+	prefs := &notificationPrefs{ID: notificationPrefsID, Parent: datastore.KeyForObj(ctx, u)}
+	switch err := datastore.Get(ctx, prefs); {
+	case err == datastore.ErrNoSuchEntity:
+		prefs.Routes = defaultRoutes()
+	case err != nil:
+		log.Warningf(ctx, "unable to get notification preferences of user %d: %v", u.ID, err)
+		prefs.Routes = defaultRoutes()
+	}
+	return prefs
+}
+
+func defaultRoutes() []string {
+	routes := make([]string, len(notificationEvents))
+	for i, event := range notificationEvents {
+		routes[i] = event + "=" + emailChannel
+	}
+	return routes
+}
+
+// channelsFor returns the channels on which the user is notified of event.
+func (prefs *notificationPrefs) channelsFor(event string) []string {
+	var chs []string
+	for _, route := range prefs.Routes {
+		if e, ch := splitPair(route); e == event {
+			chs = append(chs, ch)
+		}
+	}
+	return chs
+}
+
+// addressFor returns the address of the user on channel ch.
+func (prefs *notificationPrefs) addressFor(ch string) string {
+	for _, address := range prefs.Addresses {
+		if c, a := splitPair(address); c == ch {
+			return a
+		}
+	}
+	return ""
+}
+
+func splitPair(s string) (string, string) {
+	if i := strings.Index(s, "="); i != -1 {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
+
+// notify sends notification n to its user on each channel the user has chosen for its event.
+// It returns the first error, having tried every channel.
+func notify(ctx context.Context, n *Notification) error {
+	prefs := notificationPrefsOf(ctx, n.User)
+
+	var err error
+	for _, name := range prefs.channelsFor(n.Event) {
+		c, ok := channels[name]
+		if !ok {
+			log.Warningf(ctx, "user %d has unknown channel %q", n.User.ID, name)
+			continue
+		}
+
+		sent := *n
+		sent.Address = prefs.addressFor(name)
+		if cerr := c.Send(ctx, &sent); cerr != nil {
+			log.Warningf(ctx, "unable to send %s notification to user %d by %s: %v", n.Event, n.User.ID, name, cerr)
+			if err == nil {
+				err = cerr
+			}
+		}
+	}
+	return err
+}
+
+// sendTurnNotificationsTo notifies the player of the player's turn, unless the player is controlled by the computer.
+func (g *Game) sendTurnNotificationsTo(ctx context.Context, p *Player) error {
+	if p == nil || p.IsBot() || g.onVacation(ctx, p, time.Now()) {
+		return nil
+	}
+
+	// Turn email is formatted by the header, as for every game.
+	return notify(ctx, &Notification{
+		Event:   yourTurn,
+		User:    p.User(),
+		Subject: fmt.Sprintf("SlothNinja Games: It's Your Turn in Guild of Thieves #%d", g.ID),
+		Text:    fmt.Sprintf("It's your turn in Guild of Thieves #%d, %s.", g.ID, g.Title),
+		GameIDs: []int64{g.ID},
+		mail: func(ctx context.Context) error {
+			return g.SendTurnNotificationsTo(ctx, p)
+		},
+	})
+}
+
+// notificationPrefsView is the JSON representation of notification preferences.
+type notificationPrefsView struct {
+	Channels []string            `json:"channels"`
+	Routes   map[string][]string `json:"routes"`
+	Address  map[string]string   `json:"addresses,omitempty"`
+}
+
+func (prefs *notificationPrefs) view() notificationPrefsView {
+	v := notificationPrefsView{
+		Channels: channelNames(),
+		Routes:   make(map[string][]string),
+		Address:  make(map[string]string),
+	}
+	for _, event := range notificationEvents {
+		v.Routes[event] = prefs.channelsFor(event)
+	}
+	for _, address := range prefs.Addresses {
+		ch, a := splitPair(address)
+		v.Address[ch] = a
+	}
+	return v
+}
+
+// showNotificationPrefs responds with the notification preferences of the current user.
+func showNotificationPrefs(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	prefs := notificationPrefsOf(ctx, user.CurrentFrom(ctx))
+	c.JSON(http.StatusOK, gin.H{"version": apiVersion, "preferences": prefs.view()})
+}
+
+// updateNotificationPrefs replaces the notification preferences of the current user.  The form lists,
+// under the name of each event, the channels on which the user is notified of the event, and gives,
+// under the name of each channel other than email, the address of the user on the channel:
+// the id of a webhook of the user, or the URL of a chat service's incoming webhook.
+func updateNotificationPrefs(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	cu := user.CurrentFrom(ctx)
+	prefs := &notificationPrefs{ID: notificationPrefsID, Parent: datastore.KeyForObj(ctx, cu)}
+	if err := prefs.fromForm(ctx, c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := datastore.Put(ctx, prefs); err != nil {
+		log.Errorf(ctx, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to save notification preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"version": apiVersion, "preferences": prefs.view()})
+}
+
+func (prefs *notificationPrefs) fromForm(ctx context.Context, c *gin.Context) error {
+	used := make(map[string]bool)
+	for _, event := range notificationEvents {
+		for _, ch := range c.PostFormArray(event) {
+			if _, ok := channels[ch]; !ok {
+				return fmt.Errorf("unknown channel %q", ch)
+			}
+			prefs.Routes = append(prefs.Routes, event+"="+ch)
+			used[ch] = true
+		}
+	}
+
+	for _, ch := range channelNames() {
+		address := c.PostForm(ch)
+		switch {
+		case address == "" && used[ch] && ch != emailChannel:
+			return fmt.Errorf("the %s channel requires an address", ch)
+		case address == "":
+			continue
+		}
+
+		if err := validateAddress(ctx, prefs.Parent, ch, address); err != nil {
+			return err
+		}
+		prefs.Addresses = append(prefs.Addresses, ch+"="+address)
+	}
+	return nil
+}
+
+// validateAddress checks the address, on channel ch, of the user keyed by uk.
+func validateAddress(ctx context.Context, uk *datastore.Key, ch, address string) error {
+	switch ch {
+	case webhookChannel:
+		id, err := strconv.ParseInt(address, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid webhook %q", address)
+		}
+		if err = datastore.Get(ctx, &webhook{ID: id, Parent: uk}); err != nil {
+			return fmt.Errorf("unknown webhook %d", id)
+		}
+	case chatChannel:
+		u, err := url.Parse(address)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("a chat url must be an absolute http or https url, but is %q", address)
+		}
+	case emailChannel:
+		return fmt.Errorf("email is sent to the email of the user")
+	}
+	return nil
+}
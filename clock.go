@@ -0,0 +1,263 @@
+package got
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/user"
+	"github.com/SlothNinja/gt/engine"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/context"
+)
+
+func init() {
+	registerEntry("timeout", new(timeoutEntry))
+}
+
+// Time controls selectable when creating a game.
+const (
+	noTimeControl = ""
+	// moveTimeControl limits the time taken by each turn.
+	moveTimeControl = "move"
+	// bankTimeControl limits the total time taken by each player's turns, as with a chess clock.
+	bankTimeControl = "bank"
+)
+
+// Actions taken for a player whose turn times out.
+const (
+	passOnTimeout = "pass"
+	botOnTimeout  = "bot"
+)
+
+// timeoutBot is the strategy choosing the moves of players whose turns time out, when the game so chooses
+// or when passing is not permitted.
+const timeoutBot = greedyBot
+
+// timeLimit returns the time allowed each turn, or banked by each player.
+func (g *Game) timeLimit() time.Duration {
+	return time.Duration(g.TimeLimit) * time.Hour
+}
+
+func (g *Game) validateTimeControl() error {
+	switch g.TimeControl {
+	case noTimeControl:
+	case moveTimeControl, bankTimeControl:
+		if g.TimeLimit < 1 {
+			return fmt.Errorf("a time control must allow at least one hour, but allows %d", g.TimeLimit)
+		}
+	default:
+		return fmt.Errorf("unknown time control %q", g.TimeControl)
+	}
+
+	switch g.TimeoutAction {
+	case "", passOnTimeout, botOnTimeout:
+		return nil
+	default:
+		return fmt.Errorf("unknown timeout action %q", g.TimeoutAction)
+	}
+}
+
+// timeControlString describes the time control, such as "24 Hours Per Move".
+func (g *Game) timeControlString() string {
+	switch g.TimeControl {
+	case moveTimeControl:
+		return fmt.Sprintf("%d Hours Per Move", g.TimeLimit)
+	case bankTimeControl:
+		return fmt.Sprintf("%d Hour Bank", g.TimeLimit)
+	default:
+		return ""
+	}
+}
+
+// startClocks fills the bank of each player, under the bank time control, and starts the clock of the current player.
+func (g *Game) startClocks(ctx context.Context, now time.Time) {
+	if g.TimeControl == noTimeControl {
+		return
+	}
+
+	for _, p := range g.Players() {
+		p.Bank = g.timeLimit()
+	}
+	g.passClock(ctx, nil, now)
+}
+
+// passClock charges player p, under the bank time control, with the time taken since the clock was started,
+// excluding time on vacation, and starts the clock of the current player.
+func (g *Game) passClock(ctx context.Context, p *Player, now time.Time) {
+	if g.TimeControl == noTimeControl {
+		return
+	}
+
+	if p != nil && g.TimeControl == bankTimeControl && !g.ClockStarted.IsZero() {
+		vs := vacationsOf(ctx, p.User(), g.ClockStarted)
+		if p.Bank -= vs.activeTime(g.ClockStarted, now); p.Bank < 0 {
+			p.Bank = 0
+		}
+	}
+
+	g.ClockStarted = time.Time{}
+	if g.Status == game.Running && g.CurrentPlayer() != nil {
+		g.ClockStarted = now
+	}
+}
+
+// deadline returns when the turn of the current player times out, or false if the turn has no deadline.
+// The clock is paused while the player is on vacation.
+func (g *Game) deadline(ctx context.Context) (time.Time, bool) {
+	cp := g.CurrentPlayer()
+	if g.Status != game.Running || g.ClockStarted.IsZero() || cp == nil || cp.IsBot() {
+		return time.Time{}, false
+	}
+
+	var allowed time.Duration
+	switch g.TimeControl {
+	case moveTimeControl:
+		allowed = g.timeLimit()
+	case bankTimeControl:
+		allowed = cp.Bank
+	default:
+		return time.Time{}, false
+	}
+	return vacationsOf(ctx, cp.User(), g.ClockStarted).pausedUntil(g.ClockStarted, allowed), true
+}
+
+// timedOut indicates whether the turn of the current player had timed out by now.
+func (g *Game) timedOut(ctx context.Context, now time.Time) bool {
+	if g.TimeControl == noTimeControl {
+		return false
+	}
+	d, ok := g.deadline(ctx)
+	return ok && !now.Before(d)
+}
+
+// timeOut takes the remainder of the current player's turn once it has timed out: the player passes or,
+// if the game so chooses or passing is not permitted, a bot moves for the player.
+// It returns the entities to save with the game, should the game end.
+func (g *Game) timeOut(ctx context.Context) ([]interface{}, error) {
+	cp := g.CurrentPlayer()
+	g.newTimeoutEntryFor(cp)
+
+	r := engine.NewRand(time.Now().UnixNano())
+	for finished := false; !finished && g.Phase != gameOver; {
+		s := g.engineState()
+		ms := engine.LegalActions(s, cp.ID())
+		if len(ms) == 0 {
+			return nil, fmt.Errorf("%s has no legal move", g.NameFor(cp))
+		}
+
+		for _, a := range g.timeoutMove(s, cp, ms, &r).Actions {
+			var err error
+			if a.Type == engine.FinishTurn {
+				err, finished = g.finishTurn(ctx), true
+			} else {
+				err = g.apply(ctx, a)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if g.Phase == gameOver {
+		return g.finishGame(ctx), nil
+	}
+
+	if err := g.sendTurnNotificationsTo(ctx, g.CurrentPlayer()); err != nil {
+		log.Warningf(ctx, err.Error())
+	}
+	return nil, nil
+}
+
+// timeoutMove returns the move taken for player p, whose turn has timed out, from the legal moves ms.
+func (g *Game) timeoutMove(s *engine.State, p *Player, ms []engine.Move, r *engine.Rand) engine.Move {
+	if last := ms[len(ms)-1]; g.TimeoutAction != botOnTimeout && len(last.Actions) == 1 && last.Actions[0].Type == engine.Pass {
+		return last
+	}
+	return botStrategies[timeoutBot].Choose(s, p.ID(), ms, r)
+}
+
+type timeoutEntry struct {
+	*Entry
+}
+
+func (g *Game) newTimeoutEntryFor(p *Player) *timeoutEntry {
+	e := &timeoutEntry{
+		Entry: g.newEntryFor(p),
+	}
+	p.Log = append(p.Log, e)
+	g.Log = append(g.Log, e)
+	return e
+}
+
+func (e *timeoutEntry) HTML(g *Game) template.HTML {
+	return restful.HTML("%s ran out of time.", g.NameByPID(e.PlayerID))
+}
+
+// timeouts times out the turns of the running games whose current players have run out of time.
+// It is requested periodically by cron, and may also be requested by admins.
+func timeouts(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	if !cronOrAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only cron and admins may time out turns."})
+		return
+	}
+
+	gs, err := runningGames(ctx)
+	if err != nil {
+		log.Errorf(ctx, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to find running games"})
+		return
+	}
+
+	now := time.Now()
+	timedOut := make([]int64, 0)
+	for _, g := range gs {
+		if !g.timedOut(ctx, now) {
+			continue
+		}
+
+		es, err := g.timeOut(ctx)
+		if err == nil {
+			err = g.save(ctx, es...)
+		}
+		if err != nil {
+			log.Warningf(ctx, "unable to time out game %d: %v", g.ID, err)
+			continue
+		}
+		timedOut = append(timedOut, g.ID)
+	}
+	c.JSON(http.StatusOK, gin.H{"version": apiVersion, "timedOut": timedOut})
+}
+
+// cronOrAdmin indicates whether the request was made by cron or by an admin.
+func cronOrAdmin(c *gin.Context) bool {
+	return c.GetHeader("X-Appengine-Cron") == "true" || user.IsAdmin(restful.ContextFrom(c))
+}
+
+// runningGames returns the games in progress.  Games that cannot be retrieved are skipped.
+func runningGames(ctx context.Context) ([]*Game, error) {
+	ids, err := store.Running(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	gs := make([]*Game, 0, len(ids))
+	for _, id := range ids {
+		g := New(ctx)
+		g.ID = id
+		if err := dsGet(ctx, g); err != nil {
+			log.Warningf(ctx, "unable to get game %d: %v", id, err)
+			continue
+		}
+		gs = append(gs, g)
+	}
+	return gs, nil
+}
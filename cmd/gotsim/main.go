@@ -0,0 +1,344 @@
+// Command gotsim plays games of Guild of Thieves between bots and reports statistics on the results,
+// such as win rates by seat, for checking the balance of the rules.
+//
+// Usage:
+//
+//	gotsim [flags]
+//
+// Seats are numbered in turn order.  The bots listed by -bots are assigned to seats in order,
+// repeating the list as needed; with -rotate the assignment is rotated by one seat each game,
+// so that differences between seats are not confounded with differences between bots.
+// With -teams, the players form two teams seated alternately, and each member of a winning team is credited with the win.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SlothNinja/gt/engine"
+)
+
+var (
+	games      = flag.Int("games", 1000, "number of games to play")
+	players    = flag.Int("players", 2, "number of players in each game (2-6)")
+	twoThief   = flag.Bool("two-thief", false, "play the two thief variant")
+	teams      = flag.Bool("teams", false, "divide the players into two teams seated alternately (4 or 6 players)")
+	bots       = flag.String("bots", "random", "comma separated bots assigned to seats: random, greedy, expectimax or mcts")
+	rotate     = flag.Bool("rotate", false, "rotate the assignment of bots to seats each game")
+	seed       = flag.Int64("seed", 0, "seed of the first game; 0 seeds from the clock")
+	iterations = flag.Int("iterations", 500, "iterations per move of mcts bots")
+	workers    = flag.Int("workers", runtime.NumCPU(), "number of games played at once")
+	rulesFile  = flag.String("ruleset", "", "JSON file defining the ruleset, including any board layout; the standard ruleset if empty")
+)
+
+// ruleset is the ruleset of the games played.  Nil indicates the standard ruleset.
+var ruleset *engine.Ruleset
+
+var strategies = map[string]func() engine.Strategy{
+	"random":     func() engine.Strategy { return engine.RandomStrategy{} },
+	"greedy":     func() engine.Strategy { return engine.GreedyStrategy{} },
+	"expectimax": func() engine.Strategy { return engine.ExpectimaxStrategy{} },
+	"mcts":       func() engine.Strategy { return engine.MCTSStrategy{Iterations: *iterations} },
+}
+
+// result records the outcome of a game.
+type result struct {
+	// bots names the bot in each seat.
+	bots []string
+	// wins credits each seat with its share of the win.  Tied winners share it equally.
+	wins   []float64
+	scores []int
+	turns  int
+	// plays counts the cards played by type.
+	plays map[engine.CType]int
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("gotsim: ")
+	flag.Parse()
+
+	if *players < engine.MinPlayers || *players > engine.MaxPlayers {
+		log.Fatalf("invalid number of players: %d", *players)
+	}
+	if *teams && (*players < 4 || *players%2 != 0) {
+		log.Fatalf("invalid number of players for teams: %d", *players)
+	}
+
+	names := strings.Split(*bots, ",")
+	for _, name := range names {
+		if strategies[name] == nil {
+			log.Fatalf("unknown bot %q", name)
+		}
+	}
+
+	if *rulesFile != "" {
+		rs, err := readRuleset(*rulesFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ruleset = rs
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	results := make(chan *result)
+	seeds := make(chan int64)
+	var wg sync.WaitGroup
+	for w := 0; w < *workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for gs := range seeds {
+				r, err := play(gs, seatBots(names, int(gs-*seed)))
+				if err != nil {
+					log.Fatalf("game seeded %d: %v", gs, err)
+				}
+				results <- r
+			}
+		}()
+	}
+
+	go func() {
+		for i := 0; i < *games; i++ {
+			seeds <- *seed + int64(i)
+		}
+		close(seeds)
+		wg.Wait()
+		close(results)
+	}()
+
+	var rs []*result
+	for r := range results {
+		rs = append(rs, r)
+	}
+	report(os.Stdout, rs)
+}
+
+// readRuleset reads the JSON encoding of a ruleset from the file named name.
+// Card types are identified by their numeric values.
+func readRuleset(name string) (*engine.Ruleset, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rs := new(engine.Ruleset)
+	if err = json.NewDecoder(f).Decode(rs); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	if err = rs.Validate(*players, *twoThief); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return rs, nil
+}
+
+// seatBots returns the bots assigned to each seat for game i.
+func seatBots(names []string, i int) []string {
+	bs := make([]string, *players)
+	for seat := range bs {
+		j := seat
+		if *rotate {
+			j += i
+		}
+		bs[seat] = names[j%len(names)]
+	}
+	return bs
+}
+
+// play plays a game seeded by gs between the bots bs, listed in turn order.
+func play(gs int64, bs []string) (*result, error) {
+	pids := make([]int, len(bs))
+	ss := make([]engine.Strategy, len(bs))
+	for i, name := range bs {
+		pids[i] = i
+		ss[i] = strategies[name]()
+	}
+
+	s := engine.NewWithRuleset(pids, *twoThief, ruleset, gs)
+	if *teams {
+		s.Teams = engine.AlternateTeams(pids)
+	}
+	r := engine.NewRand(^gs)
+	res := &result{bots: bs, plays: make(map[engine.CType]int)}
+	for s.Phase != engine.PhaseGameOver {
+		cp := s.CurrentPlayer()
+		if cp == nil {
+			return nil, fmt.Errorf("no current player during the %q phase", s.Phase)
+		}
+
+		ms := engine.LegalActions(s, cp.ID)
+		if len(ms) == 0 {
+			return nil, fmt.Errorf("no legal move during the %q phase", s.Phase)
+		}
+
+		m := ss[cp.ID].Choose(s, cp.ID, ms, &r)
+		if m.Card != engine.NoType {
+			res.plays[m.Card]++
+		}
+
+		var err error
+		if s, err = engine.Play(s, m); err != nil {
+			return nil, err
+		}
+	}
+
+	res.turns = s.Turn
+	res.scores = make([]int, len(s.Players))
+	res.wins = make([]float64, len(s.Players))
+	for _, p := range s.Players {
+		res.scores[p.ID] = p.Score
+	}
+	winners := topSides(s)
+	for _, side := range winners {
+		for _, p := range side {
+			res.wins[p.ID] = 1 / float64(len(winners))
+		}
+	}
+	return res, nil
+}
+
+// sides returns the players of each team of a game or, without teams, each player alone.
+func sides(s *engine.State) [][]*engine.Player {
+	if len(s.Teams) == 0 {
+		ss := make([][]*engine.Player, len(s.Players))
+		for i, p := range s.Players {
+			ss[i] = []*engine.Player{p}
+		}
+		return ss
+	}
+
+	ss := make([][]*engine.Player, len(s.Teams))
+	for i, team := range s.Teams {
+		for _, pid := range team {
+			ss[i] = append(ss[i], s.PlayerByID(pid))
+		}
+	}
+	return ss
+}
+
+// topSides returns the winning players or teams of a finished game.
+func topSides(s *engine.State) (ss [][]*engine.Player) {
+	for _, side := range sides(s) {
+		if len(ss) == 0 {
+			ss = [][]*engine.Player{side}
+			continue
+		}
+
+		switch c := compare(side, ss[0]); {
+		case c > 0:
+			ss = [][]*engine.Player{side}
+		case c == 0:
+			ss = append(ss, side)
+		}
+	}
+	return ss
+}
+
+// compare compares the final positions of sides ps and ps2, summing over the members of each side,
+// and breaking ties in score by lamps, then camels, then cards.
+// It returns a positive number if ps is ahead, a negative number if ps2 is ahead, and zero if they are tied.
+func compare(ps, ps2 []*engine.Player) int {
+	t, t2 := totals(ps), totals(ps2)
+	for i := range t {
+		if d := t[i] - t2[i]; d != 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// totals returns the summed score, lamps, camels and cards of the players ps.
+func totals(ps []*engine.Player) (t [4]int) {
+	for _, p := range ps {
+		t[0] += p.Score
+		t[1] += engine.LampCount(p.Hand...)
+		t[2] += engine.CamelCount(p.Hand...)
+		t[3] += len(p.Hand)
+	}
+	return
+}
+
+func report(f *os.File, rs []*result) {
+	n := float64(len(rs))
+	name := engine.StandardRuleset
+	if ruleset != nil {
+		name = ruleset.Name
+	}
+	fmt.Fprintf(f, "%d games, %d players, ruleset %s, two thief variant %v, teams %v, bots %s, rotated %v\n\n",
+		len(rs), *players, name, *twoThief, *teams, *bots, *rotate)
+
+	w := tabwriter.NewWriter(f, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "Seat\tWin Rate\tAverage Score\t")
+	for seat := 0; seat < *players; seat++ {
+		var wins float64
+		var score int
+		for _, r := range rs {
+			wins += r.wins[seat]
+			score += r.scores[seat]
+		}
+		fmt.Fprintf(w, "%d\t%.3f\t%.2f\t\n", seat+1, wins/n, float64(score)/n)
+	}
+	w.Flush()
+
+	byBot := make(map[string][]float64)
+	for _, r := range rs {
+		for seat, name := range r.bots {
+			byBot[name] = append(byBot[name], r.wins[seat])
+		}
+	}
+	if len(byBot) > 1 {
+		fmt.Fprintln(f)
+		w = tabwriter.NewWriter(f, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "Bot\tWin Rate\t")
+		for _, name := range sortedKeys(byBot) {
+			var wins float64
+			for _, v := range byBot[name] {
+				wins += v
+			}
+			fmt.Fprintf(w, "%s\t%.3f\t\n", name, wins/float64(len(byBot[name])))
+		}
+		w.Flush()
+	}
+
+	var turns int
+	plays := make(map[engine.CType]int)
+	for _, r := range rs {
+		turns += r.turns
+		for t, c := range r.plays {
+			plays[t] += c
+		}
+	}
+	fmt.Fprintf(f, "\nAverage game length: %.2f turns\n\n", float64(turns)/n)
+
+	w = tabwriter.NewWriter(f, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "Card\tPlays per Game\t")
+	for _, t := range engine.CardTypes() {
+		if t == engine.Guard {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%.2f\t\n", t.IDString(), float64(plays[t])/n)
+	}
+	w.Flush()
+}
+
+func sortedKeys(m map[string][]float64) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
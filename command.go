@@ -0,0 +1,179 @@
+package got
+
+import (
+	"net/http"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"github.com/SlothNinja/gt/engine"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/context"
+)
+
+// apiVersion is the version of the JSON command API.
+const apiVersion = 1
+
+// Command types accepted by the JSON command API.
+const (
+	placeThiefCommand  = "place-thief"
+	playCardCommand    = "play-card"
+	selectThiefCommand = "select-thief"
+	moveThiefCommand   = "move-thief"
+	passCommand        = "pass"
+	finishTurnCommand  = "finish-turn"
+	undoCommand        = "undo"
+)
+
+// command is a turn action submitted to the JSON command API.
+// Row and Column are zero-based grid indices; Card is a card id such as "sword" or "start-camel".
+type command struct {
+	Type   string `json:"type" binding:"required"`
+	Row    int    `json:"row"`
+	Column int    `json:"column"`
+	Card   string `json:"card"`
+}
+
+// commandResult is the response of the JSON command API.
+type commandResult struct {
+	Version int          `json:"version"`
+	State   *gameView    `json:"state"`
+	Entries []*entryView `json:"entries"`
+}
+
+// entryView is the JSON representation of a game log entry.
+type entryView struct {
+	Phase string `json:"phase"`
+	Turn  int    `json:"turn"`
+	Round int    `json:"round"`
+	Text  string `json:"text"`
+}
+
+func (g *Game) entryViews(es GameLog) []*entryView {
+	vs := make([]*entryView, len(es))
+	for i, e := range es {
+		vs[i] = &entryView{
+			Phase: e.PhaseName(),
+			Turn:  e.Turn(),
+			Round: e.Round(),
+			Text:  string(e.HTML(g)),
+		}
+	}
+	return vs
+}
+
+func commandError(c *gin.Context, code int, err error) {
+	c.JSON(code, gin.H{"version": apiVersion, "error": err.Error()})
+}
+
+// action returns the rules engine action requested by the command on behalf of the current player.
+func (cmd *command) action(g *Game) (engine.Action, error) {
+	a := engine.Action{
+		PlayerID: g.CurrentPlayer().ID(),
+		Position: engine.Position{Row: cmd.Row, Column: cmd.Column},
+	}
+
+	switch cmd.Type {
+	case placeThiefCommand:
+		a.Type = engine.PlaceThief
+	case playCardCommand:
+		a.Type = engine.PlayCard
+		if a.Card = engine.ToCType(cmd.Card); a.Card == engine.NoType {
+			return a, sn.NewVError("%q is not a valid card.", cmd.Card)
+		}
+	case selectThiefCommand:
+		a.Type = engine.SelectThief
+	case moveThiefCommand:
+		a.Type = engine.MoveThief
+	case passCommand:
+		a.Type = engine.Pass
+	default:
+		return a, sn.NewVError("%q is not a valid command.", cmd.Type)
+	}
+	return a, nil
+}
+
+// execute performs the command, returning the game following the command.
+// Undo returns the game as restored from the undo stack.
+func (g *Game) execute(ctx context.Context, cmd *command) (*Game, error) {
+	switch cmd.Type {
+	case finishTurnCommand:
+		return g, g.finishCurrentTurn(ctx)
+	case undoCommand:
+		if _, _, err := g.undoStep(ctx); err != nil {
+			return g, err
+		}
+		if err := g.updateUndoStack(ctx, game.Undo); err != nil {
+			return g, err
+		}
+		return g.reload(ctx)
+	}
+
+	a, err := cmd.action(g)
+	if err != nil {
+		return g, err
+	}
+
+	if err = g.apply(ctx, a); err != nil {
+		return g, err
+	}
+	return g, g.updateUndoStack(ctx, game.Cache)
+}
+
+// reload returns the game as currently shown, pulling it from the undo stack if cached and otherwise from the datastore.
+func (g *Game) reload(ctx context.Context) (*Game, error) {
+	rg := New(ctx)
+	rg.ID = g.ID
+	if err := mcGet(ctx, rg); err == nil {
+		return rg, nil
+	}
+
+	rg = New(ctx)
+	rg.ID = g.ID
+	if err := dsGet(ctx, rg); err != nil {
+		return g, err
+	}
+	return rg, nil
+}
+
+func commandAction(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	g := gameFrom(ctx)
+	if g == nil {
+		commandError(c, http.StatusNotFound, sn.NewVError("Game not found."))
+		return
+	}
+
+	cmd := new(command)
+	if err := c.ShouldBindJSON(cmd); err != nil {
+		commandError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if g.CurrentPlayer() == nil || !g.CUserIsCPlayerOrAdmin(ctx) {
+		commandError(c, http.StatusForbidden, sn.NewVError("Only the current player may perform this action."))
+		return
+	}
+
+	n := len(g.Log)
+	g, err := g.execute(ctx, cmd)
+	switch {
+	case err != nil && sn.IsVError(err):
+		commandError(c, http.StatusUnprocessableEntity, err)
+		return
+	case err != nil:
+		log.Errorf(ctx, "g.execute error: %v", err)
+		commandError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	r := &commandResult{Version: apiVersion, State: g.viewFor(ctx), Entries: []*entryView{}}
+	if len(g.Log) > n {
+		r.Entries = g.entryViews(g.Log[n:])
+	}
+	c.JSON(http.StatusOK, r)
+}
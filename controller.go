@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
-	"time"
 
 	"bitbucket.org/SlothNinja/slothninja-games/sn"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/codec"
@@ -17,9 +16,7 @@ import (
 	"bitbucket.org/SlothNinja/slothninja-games/sn/user"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/user/stats"
 	"github.com/gin-gonic/gin"
-	"go.chromium.org/gae/service/datastore"
 	"go.chromium.org/gae/service/info"
-	"go.chromium.org/gae/service/memcache"
 	"golang.org/x/net/context"
 )
 
@@ -54,9 +51,19 @@ func show(prefix string) gin.HandlerFunc {
 		log.Debugf(ctx, "Entering")
 		defer log.Debugf(ctx, "Exiting")
 
+		if isJSONRequest(c) {
+			showJSON(c)
+			return
+		}
+
 		g := gameFrom(ctx)
 		cu := user.CurrentFrom(ctx)
-		c.HTML(http.StatusOK, prefix+"/show", gin.H{
+		stack, err := undoStackFor(ctx, g)
+		if err != nil {
+			log.Warningf(ctx, "undoStackFor error: %v", err)
+			stack = newUndoStack()
+		}
+		d := gin.H{
 			"Context":    ctx,
 			"VersionID":  info.VersionID(ctx),
 			"CUser":      cu,
@@ -65,7 +72,13 @@ func show(prefix string) gin.HandlerFunc {
 			"Admin":      game.AdminFrom(ctx),
 			"MessageLog": mlog.From(ctx),
 			"ColorMap":   color.MapFrom(ctx),
-		})
+			"CanUndo":    stack.CanUndo(),
+			"CanRedo":    stack.CanRedo(),
+		}
+		if g.Phase == gameOver && g.Analysis != nil {
+			d["Analysis"] = g.Analysis
+		}
+		c.HTML(http.StatusOK, prefix+"/show", d)
 	}
 }
 
@@ -86,7 +99,11 @@ func (g *Game) update(ctx context.Context) (tmpl string, act game.ActionType, er
 	case "pass":
 		tmpl, act, err = g.pass(ctx)
 	case "undo":
-		tmpl, act, err = g.undoTurn(ctx)
+		tmpl, act, err = g.undoStep(ctx)
+	case "redo":
+		tmpl, act, err = g.redoStep(ctx)
+	case "reset":
+		tmpl, act, err = g.resetTurn(ctx)
 	default:
 		act, err = game.None, fmt.Errorf("%v is not a valid action", a)
 	}
@@ -94,6 +111,18 @@ func (g *Game) update(ctx context.Context) (tmpl string, act game.ActionType, er
 }
 
 func undo(prefix string) gin.HandlerFunc {
+	return stepAction(prefix, (*Game).undoStep)
+}
+
+func redo(prefix string) gin.HandlerFunc {
+	return stepAction(prefix, (*Game).redoStep)
+}
+
+func reset(prefix string) gin.HandlerFunc {
+	return stepAction(prefix, (*Game).resetTurn)
+}
+
+func stepAction(prefix string, f func(*Game, context.Context) (string, game.ActionType, error)) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		ctx := restful.ContextFrom(c)
 		log.Debugf(ctx, "Entering")
@@ -105,9 +134,17 @@ func undo(prefix string) gin.HandlerFunc {
 			log.Errorf(ctx, "Controller#Update Game Not Found")
 			return
 		}
-		mkey := g.UndoKey(ctx)
-		if err := memcache.Delete(ctx, mkey); err != nil && err != memcache.ErrCacheMiss {
-			log.Errorf(ctx, "Controller#Undo Error: %s", err)
+
+		_, act, err := f(g, ctx)
+		if err == nil {
+			err = g.updateUndoStack(ctx, act)
+		}
+
+		switch {
+		case err != nil && sn.IsVError(err):
+			restful.AddErrorf(ctx, "%v", err)
+		case err != nil:
+			log.Errorf(ctx, "g.updateUndoStack error: %v", err)
 		}
 	}
 }
@@ -133,18 +170,12 @@ func update(prefix string) gin.HandlerFunc {
 			log.Errorf(ctx, err.Error())
 			c.Redirect(http.StatusSeeOther, homePath)
 			return
-		case actionType == game.Cache:
-			mkey := g.UndoKey(ctx)
-			item := memcache.NewItem(ctx, mkey).SetExpiration(time.Minute * 30)
-			v, err := codec.Encode(g)
-			if err != nil {
-				log.Errorf(ctx, err.Error())
-				c.Redirect(http.StatusSeeOther, showPath(prefix, c.Param("hid")))
-				return
-			}
-			item.SetValue(v)
-			if err := memcache.Set(ctx, item); err != nil {
-				log.Errorf(ctx, err.Error())
+		case actionType == game.Cache, actionType == game.Undo, actionType == game.Redo, actionType == game.Reset:
+			switch err := g.updateUndoStack(ctx, actionType); {
+			case err != nil && sn.IsVError(err):
+				restful.AddErrorf(ctx, "%v", err)
+			case err != nil:
+				log.Errorf(ctx, "g.updateUndoStack error: %v", err)
 				c.Redirect(http.StatusSeeOther, showPath(prefix, c.Param("hid")))
 				return
 			}
@@ -171,17 +202,11 @@ func update(prefix string) gin.HandlerFunc {
 				c.Redirect(http.StatusSeeOther, showPath(prefix, c.Param("hid")))
 				return
 			}
-		case actionType == game.Undo:
-			mkey := g.UndoKey(ctx)
-			if err := memcache.Delete(ctx, mkey); err != nil && err != memcache.ErrCacheMiss {
-				log.Errorf(ctx, "memcache.Delete error: %s", err)
-				c.Redirect(http.StatusSeeOther, showPath(prefix, c.Param("hid")))
-			}
 		}
 
 		switch jData := jsonFrom(ctx); {
 		case jData != nil && template == "json":
-			c.JSON(http.StatusOK, jData)
+			c.JSON(http.StatusOK, jData.viewFor(ctx))
 		case template == "":
 			c.Redirect(http.StatusSeeOther, showPath(prefix, c.Param("hid")))
 		default:
@@ -201,37 +226,25 @@ func update(prefix string) gin.HandlerFunc {
 	}
 }
 
-func (g *Game) save(ctx context.Context, es ...interface{}) (err error) {
-	err = datastore.RunInTransaction(ctx, func(tc context.Context) (terr error) {
-		oldG := New(tc)
-		if ok := datastore.PopulateKey(oldG.Header, datastore.KeyForObj(tc, g.Header)); !ok {
-			terr = fmt.Errorf("unable to populate game with key")
-			return
-		}
-
-		if terr = datastore.Get(tc, oldG.Header); terr != nil {
-			return
-		}
-
-		if oldG.UpdatedAt != g.UpdatedAt {
-			terr = fmt.Errorf("game state changed unexpectantly -- try again")
-			return
-		}
+func (g *Game) save(ctx context.Context, es ...interface{}) error {
+	n, err := g.savedLogLength()
+	if err != nil {
+		log.Warningf(ctx, "g.savedLogLength error: %v", err)
+		n = len(g.Log)
+	}
 
-		if terr = g.encode(ctx); terr != nil {
-			return
-		}
+	if err = g.encode(ctx); err != nil {
+		return err
+	}
 
-		if terr = datastore.Put(tc, append(es, g.Header)); terr != nil {
-			return
-		}
+	if err = store.Save(ctx, g, es...); err != nil {
+		return err
+	}
 
-		if terr = memcache.Delete(tc, g.UndoKey(tc)); terr == memcache.ErrCacheMiss {
-			terr = nil
-		}
-		return
-	}, &datastore.TransactionOptions{XG: true})
-	return
+	g.publish(ctx, n)
+	g.deliverEvents(ctx)
+	g.scheduleBots(ctx)
+	return nil
 }
 
 func (g *Game) encode(ctx context.Context) (err error) {
@@ -241,7 +254,7 @@ func (g *Game) encode(ctx context.Context) (err error) {
 	g.TempData = nil
 
 	var encoded []byte
-	if encoded, err = codec.Encode(g.State); err != nil {
+	if encoded, err = encodeState(g.State); err != nil {
 		return
 	}
 	g.SavedState = encoded
@@ -404,23 +417,37 @@ func create(prefix string) gin.HandlerFunc {
 		}
 
 		if err == nil {
-			err = g.encode(ctx)
+			err = g.validateOptions()
 		}
 
 		if err == nil {
-			err = datastore.RunInTransaction(ctx, func(tc context.Context) (err error) {
-				if err = datastore.Put(tc, g.Header); err != nil {
-					return
-				}
+			g.queueEvent(&gameEvent{Event: gameCreated})
+		}
 
-				m := mlog.New()
-				m.ID = g.ID
-				return datastore.Put(tc, m)
+		// Bots are seated when the game starts, so only recruit players for the remaining seats.
+		// A game needing no further players, such as a solo game, starts immediately.
+		if err == nil && (g.Bots > 0 || g.isSolo()) {
+			g.NumPlayers -= g.Bots
+			if len(g.Users) == g.NumPlayers {
+				err = g.Start(ctx)
+			}
+		}
 
-			}, &datastore.TransactionOptions{XG: true})
+		if err == nil {
+			err = g.encode(ctx)
 		}
 
 		if err == nil {
+			err = store.Create(ctx, g, func(id int64) []interface{} {
+				m := mlog.New()
+				m.ID = id
+				return []interface{}{m}
+			})
+		}
+
+		if err == nil {
+			g.deliverEvents(ctx)
+			g.scheduleBots(ctx)
 			restful.AddNoticef(ctx, "<div>%s created.</div>", g.Title)
 		} else {
 			log.Errorf(ctx, err.Error())
@@ -446,9 +473,15 @@ func accept(prefix string) gin.HandlerFunc {
 			err   error
 		)
 
+		var cp *Player
 		u := user.CurrentFrom(ctx)
-		if start, err = g.Accept(ctx, u); err == nil && start {
+		if start, err = g.Accept(ctx, u); err == nil {
+			g.queueEvent(userEvent(playerAccepted, u))
+		}
+
+		if err == nil && start {
 			err = g.Start(ctx)
+			cp = g.CurrentPlayer()
 		}
 
 		if err == nil {
@@ -456,7 +489,7 @@ func accept(prefix string) gin.HandlerFunc {
 		}
 
 		if err == nil && start {
-			g.SendTurnNotificationsTo(ctx, g.CurrentPlayer())
+			g.sendTurnNotificationsTo(ctx, cp)
 		}
 
 		if err != nil {
@@ -483,6 +516,7 @@ func drop(prefix string) gin.HandlerFunc {
 
 		u := user.CurrentFrom(ctx)
 		if err = g.Drop(u); err == nil {
+			g.queueEvent(userEvent(playerDropped, u))
 			err = g.save(ctx)
 		}
 
@@ -500,7 +534,7 @@ func fetch(c *gin.Context) {
 	defer log.Debugf(ctx, "Exiting")
 	// create Gamer
 	log.Debugf(ctx, "hid: %v", c.Param("hid"))
-	id, err := strconv.ParseInt(c.Param("hid"), 10, 64)
+	id, err := strconv.ParseInt(hidFrom(c), 10, 64)
 	if err != nil {
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -511,12 +545,9 @@ func fetch(c *gin.Context) {
 	g.ID = id
 
 	switch action := c.PostForm("action"); {
-	case action == "reset":
-		// pull from memcache/datastore
-		// same as undo
-		fallthrough
-	case action == "undo":
-		// pull from memcache/datastore
+	case action == "reset", action == "undo", action == "redo":
+		// pull from datastore
+		// the undo stack determines the state shown
 		if err := dsGet(ctx, g); err != nil {
 			c.Redirect(http.StatusSeeOther, homePath)
 			return
@@ -530,7 +561,6 @@ func fetch(c *gin.Context) {
 		}
 
 		log.Debugf(ctx, "g: %#v", g)
-		log.Debugf(ctx, "k: %v", datastore.KeyForObj(ctx, g.Header))
 		if err := dsGet(ctx, g); err != nil {
 			log.Debugf(ctx, "dsGet error: %v", err)
 			c.Redirect(http.StatusSeeOther, homePath)
@@ -544,17 +574,33 @@ func mcGet(ctx context.Context, g *Game) (err error) {
 	log.Debugf(ctx, "Entering")
 	defer log.Debugf(ctx, "Exiting")
 
-	var (
-		mkey string
-		item memcache.Item
-	)
+	var stack *undoStack
+	if stack, err = undoStackFor(ctx, g); err != nil {
+		return
+	}
+
+	v := stack.current()
+	if v == nil {
+		err = ErrCacheMiss
+		return
+	}
 
-	mkey = g.GetHeader().UndoKey(ctx)
-	if item, err = memcache.GetKey(ctx, mkey); err != nil {
+	// The turn in progress is stale if the game was saved since it was cached, say, because the turn timed out.
+	h := *g.Header
+	if err = store.Get(ctx, &Game{Header: &h}); err != nil {
 		return
 	}
 
-	if err = codec.Decode(g, item.Value()); err != nil {
+	if err = codec.Decode(g, v); err != nil {
+		return
+	}
+
+	if !h.UpdatedAt.Equal(g.UpdatedAt) {
+		log.Warningf(ctx, "discarding turn cached for game %d: the game was saved since", g.ID)
+		if err = store.Uncache(ctx, g); err != nil {
+			return
+		}
+		err = ErrCacheMiss
 		return
 	}
 
@@ -573,7 +619,7 @@ func dsGet(ctx context.Context, g *Game) (err error) {
 	log.Debugf(ctx, "Entering")
 	defer log.Debugf(ctx, "Exiting")
 
-	switch err = datastore.Get(ctx, g.Header); {
+	switch err = store.Get(ctx, g); {
 	case err != nil:
 		restful.AddErrorf(ctx, err.Error())
 		return
@@ -583,14 +629,11 @@ func dsGet(ctx context.Context, g *Game) (err error) {
 		return
 	}
 
-	s := newState()
-	if err = codec.Decode(&s, g.SavedState); err != nil {
+	if err = g.loadState(ctx); err != nil {
 		restful.AddErrorf(ctx, err.Error())
 		return
 	}
 
-	g.State = s
-
 	if err = g.init(ctx); err != nil {
 		restful.AddErrorf(ctx, err.Error())
 		return
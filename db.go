@@ -1,10 +1,14 @@
 package got
 
 import (
+	"fmt"
+	"strings"
+
 	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/type"
+	"github.com/SlothNinja/gt/engine"
 	"github.com/gin-gonic/gin/binding"
 	"go.chromium.org/gae/service/datastore"
 	"golang.org/x/net/context"
@@ -47,11 +51,44 @@ func (g *Game) afterCache() error {
 	return g.init(g.CTX())
 }
 
-func (g *Game) options() (s string) {
+func (g *Game) options() string {
+	var opts []string
 	if g.TwoThiefVariant {
-		s = "Two Thief Variant"
+		opts = append(opts, "Two Thief Variant")
 	}
-	return
+	if rs := g.ruleset(); rs.Name != engine.StandardRuleset {
+		opts = append(opts, fmt.Sprintf("Ruleset: %s", rs.Name))
+	}
+	if l := g.ruleset().Layout; l != nil {
+		opts = append(opts, fmt.Sprintf("Layout: %s", l.Name))
+	}
+	if g.TeamPlay {
+		opts = append(opts, "Team Play")
+	}
+	if g.isSolo() {
+		opts = append(opts, fmt.Sprintf("Puzzle: %s", g.Scenario.Name))
+	}
+	if tc := g.timeControlString(); tc != "" {
+		opts = append(opts, tc)
+	}
+	if g.Bots > 0 {
+		opts = append(opts, fmt.Sprintf("%s Bots: %d", botLevelNames[g.botLevel()], g.Bots))
+	}
+	return strings.Join(opts, ", ")
+}
+
+// validateOptions returns an error if the game cannot be played with the options chosen when creating it.
+func (g *Game) validateOptions() error {
+	if g.isSolo() {
+		return g.validateScenario()
+	}
+
+	for _, validate := range []func() error{g.validateNumPlayers, g.validateBots, g.validateRuleset, g.validateTeams, g.validateTimeControl} {
+		if err := validate(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (g *Game) fromForm(ctx context.Context) (err error) {
@@ -61,6 +98,15 @@ func (g *Game) fromForm(ctx context.Context) (err error) {
 	s := new(State)
 	if err = restful.BindWith(ctx, s, binding.FormPost); err == nil {
 		g.TwoThiefVariant = s.TwoThiefVariant
+		g.Bots = s.Bots
+		g.BotLevel = s.BotLevel
+		g.TeamPlay = s.TeamPlay
+		g.TimeControl = s.TimeControl
+		g.TimeLimit = s.TimeLimit
+		g.TimeoutAction = s.TimeoutAction
+		if err = g.rulesetFromForm(ctx); err == nil {
+			err = g.scenarioFromForm(ctx)
+		}
 	}
 	log.Debugf(ctx, "err: %v s:%#v", err, s)
 	return
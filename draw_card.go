@@ -1,49 +1,31 @@
 package got
 
 import (
-	"encoding/gob"
 	"html/template"
 
 	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
-	"golang.org/x/net/context"
+	"github.com/SlothNinja/gt/engine"
 )
 
 func init() {
-	gob.Register(new(drawCardEntry))
-}
-
-func (g *Game) drawCard(ctx context.Context) (tmp string, err error) {
-	g.Phase = drawCard
-	cp := g.CurrentPlayer()
-
-	if g.Turn != 1 {
-		card, shuffle := cp.draw()
-		e := g.newDrawCardEntryFor(cp, card, shuffle)
-		restful.AddNoticef(ctx, string(e.HTML(g)))
-		if g.PlayedCard.Type == coins {
-			card, shuffle := cp.draw()
-			e := g.newDrawCardEntryFor(cp, card, shuffle)
-			restful.AddNoticef(ctx, string(e.HTML(g)))
-		}
-	}
-	cp.PerformedAction = true
-	return "got/move_thief_update", nil
+	registerEntry("draw-card", new(drawCardEntry))
 }
 
 type drawCardEntry struct {
 	*Entry
 	Card    Card
 	Shuffle bool
+	RandPos uint64
 }
 
-func (g *Game) newDrawCardEntryFor(p *Player, c *Card, shuffle bool) *drawCardEntry {
+func (g *Game) newDrawCardEntry(de *engine.DrawCardEvent) *drawCardEntry {
 	e := &drawCardEntry{
-		Entry:   g.newEntryFor(p),
-		Card:    *c,
-		Shuffle: shuffle,
+		Entry:   g.newEntryFromEvent(de),
+		Card:    de.Card,
+		Shuffle: de.Shuffle,
+		RandPos: de.RandPos,
 	}
-	p.Log = append(p.Log, e)
-	g.Log = append(g.Log, e)
+	g.addEntry(de.PlayerID, e)
 	return e
 }
 
@@ -56,18 +38,3 @@ func (e *drawCardEntry) HTML(g *Game) (t template.HTML) {
 	}
 	return
 }
-
-func (p *Player) draw() (*Card, bool) {
-	shuffle := false
-	if len(p.DrawPile) == 0 {
-		shuffle = true
-		p.DrawPile = p.DiscardPile
-		for _, card := range p.DrawPile {
-			card.FaceUp = false
-		}
-		p.DiscardPile = make(Cards, 0)
-	}
-	card := p.DrawPile.draw()
-	p.Hand.append(card)
-	return card, shuffle
-}
@@ -1,8 +1,6 @@
 package got
 
 import (
-	"bytes"
-	"encoding/gob"
 	"fmt"
 	"html/template"
 
@@ -11,15 +9,14 @@ import (
 	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/rating"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
-	"bitbucket.org/SlothNinja/slothninja-games/sn/send"
+	"github.com/SlothNinja/gt/engine"
 	"github.com/gin-gonic/gin"
-	"go.chromium.org/gae/service/mail"
 	"golang.org/x/net/context"
 )
 
 func init() {
-	gob.Register(new(endGameEntry))
-	gob.Register(new(announceWinnersEntry))
+	registerEntry("end-game", new(endGameEntry))
+	registerEntry("announce-winners", new(announceWinnersEntry))
 }
 
 func (g *Game) endGame(ctx context.Context) (ps contest.Places) {
@@ -27,12 +24,35 @@ func (g *Game) endGame(ctx context.Context) (ps contest.Places) {
 	defer log.Debugf(ctx, "Exiting")
 
 	g.Phase = endGame
-	ps = g.determinePlaces(ctx)
-	g.setWinners(ps[0])
+	switch {
+	case g.isSolo():
+		// Solo games are unrated.  The player wins by reaching the goal.
+		g.setWinningPlayers(g.solvers())
+	case g.hasBots():
+		// Games including computer controlled players are unrated.
+		g.setWinningPlayers(g.topScorers())
+	default:
+		ps = g.determinePlaces(ctx)
+		g.setWinners(ps[0])
+	}
 	g.newEndGameEntry()
 	return
 }
 
+// topScorers returns the players having the highest score or, in a team game, the members of the teams having the highest score.
+func (g *Game) topScorers() (ps Players) {
+	best := 0
+	for _, t := range g.teams() {
+		switch score := t.score(); {
+		case len(ps) == 0 || score > best:
+			ps, best = append(Players(nil), t...), score
+		case score == best:
+			ps = append(ps, t...)
+		}
+	}
+	return
+}
+
 func toIDS(places []Players) [][]int64 {
 	sids := make([][]int64, len(places))
 	for i, players := range places {
@@ -56,11 +76,19 @@ func (g *Game) newEndGameEntry() {
 
 func (e *endGameEntry) HTML(g *Game) (s template.HTML) {
 	rows := restful.HTML("")
-	for _, p := range g.Players() {
-		rows += restful.HTML("<tr>")
-		rows += restful.HTML("<td>%s</td> <td>%d</td> <td>%d</td> <td>%d</td> <td>%d</td>",
-			g.NameFor(p), p.Score, lampCount(p.Hand...), camelCount(p.Hand...), len(p.Hand))
-		rows += restful.HTML("</tr>")
+	for i, t := range g.teams() {
+		for _, p := range t {
+			rows += restful.HTML("<tr>")
+			rows += restful.HTML("<td>%s</td> <td>%d</td> <td>%d</td> <td>%d</td> <td>%d</td>",
+				g.NameFor(p), p.Score, engine.LampCount(p.Hand...), engine.CamelCount(p.Hand...), len(p.Hand))
+			rows += restful.HTML("</tr>")
+		}
+		if g.isTeamGame() {
+			rows += restful.HTML("<tr>")
+			rows += restful.HTML("<td><strong>Team %d</strong></td> <td><strong>%d</strong></td> <td>%d</td> <td>%d</td> <td>%d</td>",
+				i+1, t.score(), t.lamps(), t.camels(), t.cards())
+			rows += restful.HTML("</tr>")
+		}
 	}
 	s += restful.HTML("<table class='strippedDataTable'><thead><tr><th>Player</th><th>Score</th>")
 	s += restful.HTML("<th>Lamps</th><th>Camels</th><th>Cards</th></tr></thead><tbody>")
@@ -70,13 +98,20 @@ func (e *endGameEntry) HTML(g *Game) (s template.HTML) {
 }
 
 func (g *Game) setWinners(rmap contest.ResultsMap) {
+	var ps Players
+	for key := range rmap {
+		ps = append(ps, g.PlayerByUserID(key.IntID()))
+	}
+	g.setWinningPlayers(ps)
+}
+
+func (g *Game) setWinningPlayers(ps Players) {
 	g.Phase = announceWinners
 	g.Status = game.Completed
 
 	g.setCurrentPlayers()
 	g.WinnerIDS = nil
-	for key := range rmap {
-		p := g.PlayerByUserID(key.IntID())
+	for _, p := range ps {
 		g.WinnerIDS = append(g.WinnerIDS, p.ID())
 	}
 
@@ -84,70 +119,85 @@ func (g *Game) setWinners(rmap contest.ResultsMap) {
 }
 
 type result struct {
-	Place, GLO, Score int
-	Name, Inc         string
+	Place int    `json:"place"`
+	GLO   int    `json:"glo"`
+	Score int    `json:"score"`
+	Name  string `json:"name"`
+	Inc   string `json:"inc"`
 }
 
 type results []result
 
-func (g *Game) sendEndGameNotifications(ctx context.Context, ps contest.Places, cs contest.Contests) (err error) {
-	log.Debugf(ctx, "Entering")
-	defer log.Debugf(ctx, "Exiting")
-
-	g.Phase = gameOver
-	g.Status = game.Completed
-	rs := make(results, g.NumPlayers)
+// endGameResults returns the places, scores and rating changes of the players, given the places ps and
+// the contests cs of the game, or nil without places.  It relies on the ratings from before the contests are saved.
+func (g *Game) endGameResults(ctx context.Context, ps contest.Places, cs contest.Contests) (rs results, err error) {
+	if len(ps) == 0 {
+		return
+	}
+	rs = make(results, 0, g.NumPlayers)
 
-	var (
-		i      int
-		cr, nr *rating.CurrentRating
-	)
 	for place, rmap := range ps {
 		for k := range rmap {
 			p := g.PlayerByUserID(k.IntID())
-			cr, nr, err = rating.IncreaseFor(ctx, p.User(), g.Type, cs)
+			cr, nr, err := rating.IncreaseFor(ctx, p.User(), g.Type, cs)
+			if err != nil {
+				return nil, err
+			}
 			clo, nlo := cr.Rank().GLO(), nr.Rank().GLO()
 			inc := nlo - clo
 
-			rs[i] = result{
+			rs = append(rs, result{
 				Place: place,
 				GLO:   nlo,
 				Score: p.Score,
 				Name:  g.NameFor(p),
 				Inc:   fmt.Sprintf("%+d", inc),
-			}
+			})
 		}
-		i++
 	}
+	return
+}
+
+func (g *Game) sendEndGameNotifications(ctx context.Context, rs results) (err error) {
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	g.Phase = gameOver
+	g.Status = game.Completed
 
 	var names []string
 	for _, p := range g.winners() {
 		names = append(names, g.NameFor(p))
 	}
 
-	ts := restful.TemplatesFrom(ctx)
-	buf := new(bytes.Buffer)
-	tmpl := ts["got/end_game_notification"]
-	if err = tmpl.Execute(buf, gin.H{
+	body, err := notificationBody(ctx, "got/end_game_notification", nil, gin.H{
 		"Results": rs,
 		"Winners": restful.ToSentence(names),
-	}); err != nil {
+	})
+	if err != nil {
 		return
 	}
 
-	ms := make([]*mail.Message, len(g.Players()))
-	sender := "webmaster@slothninja.com"
 	subject := fmt.Sprintf("SlothNinja Games: Guild of Thieves #%d Has Ended", g.ID)
-	body := buf.String()
-	for i, p := range g.Players() {
-		ms[i] = &mail.Message{
-			To:       []string{p.User().Email},
-			Sender:   sender,
+	text := fmt.Sprintf("Guild of Thieves #%d, %s, has ended.", g.ID, g.Title)
+	if len(names) > 0 {
+		text += fmt.Sprintf("  Congratulations to %s.", restful.ToSentence(names))
+	}
+	for _, p := range g.Players() {
+		if p.IsBot() {
+			continue
+		}
+		if perr := notify(ctx, &Notification{
+			Event:    gameEnded,
+			User:     p.User(),
 			Subject:  subject,
 			HTMLBody: body,
+			Text:     text,
+			GameIDs:  []int64{g.ID},
+		}); perr != nil && err == nil {
+			err = perr
 		}
 	}
-	err = send.Message(ctx, ms...)
 	return
 }
 
@@ -164,6 +214,9 @@ func (g *Game) newAnnounceWinnersEntry() *announceWinnersEntry {
 }
 
 func (e *announceWinnersEntry) HTML(g *Game) template.HTML {
+	if g.isSolo() && len(g.winners()) == 0 {
+		return restful.HTML("The goal, to %s, was not reached.", g.Scenario.Goal)
+	}
 	names := make([]string, len(g.winners()))
 	for i, winner := range g.winners() {
 		names[i] = g.NameFor(winner)
@@ -0,0 +1,137 @@
+package got
+
+import (
+	"bitbucket.org/SlothNinja/slothninja-games/sn"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"github.com/SlothNinja/gt/engine"
+	"golang.org/x/net/context"
+)
+
+// engineState returns a rules engine representation of the game.
+func (g *Game) engineState() *engine.State {
+	s := &engine.State{
+		Grid:            g.Grid,
+		Jewels:          g.Jewels,
+		TwoThiefVariant: g.TwoThiefVariant,
+		Phase:           engine.Phase(g.Phase),
+		Turn:            g.Turn,
+		Round:           g.Round,
+		CurrentPlayerID: noPID,
+		Rand:            g.Rand,
+		Ruleset:         g.Ruleset,
+		Teams:           g.Teams,
+		BumpedPlayerID:  noPID,
+	}
+	if g.isSolo() {
+		s.LastTurn = g.Scenario.LastTurn()
+	}
+
+	for _, p := range g.Players() {
+		s.Players = append(s.Players, &engine.Player{
+			ID:              p.ID(),
+			Score:           p.Score,
+			Passed:          p.Passed,
+			PerformedAction: p.PerformedAction,
+			Hand:            p.Hand,
+			DrawPile:        p.DrawPile,
+			DiscardPile:     p.DiscardPile,
+		})
+	}
+
+	if cp := g.CurrentPlayer(); cp != nil {
+		s.CurrentPlayerID = cp.ID()
+	}
+
+	if g.TempData != nil {
+		s.PlayedCard = g.PlayedCard
+		s.JewelsPlayed = g.JewelsPlayed
+		s.Stepped = g.Stepped
+		s.BumpedPlayerID = g.BumpedPlayerID
+		if a := g.SelectedThiefArea(); a != nil {
+			pos := a.Position()
+			s.SelectedThief = &pos
+		}
+	}
+	return s
+}
+
+// setEngineState updates the game to reflect the rules engine state s.
+func (g *Game) setEngineState(s *engine.State) {
+	g.Grid = s.Grid
+	g.Jewels = s.Jewels
+	g.TwoThiefVariant = s.TwoThiefVariant
+	g.Phase = game.Phase(s.Phase)
+	g.Turn = s.Turn
+	g.Round = s.Round
+	g.Rand = s.Rand
+	g.Ruleset = s.Ruleset
+	g.Teams = s.Teams
+
+	for _, ep := range s.Players {
+		if p := g.PlayerByID(ep.ID); p != nil {
+			p.Score = ep.Score
+			p.Passed = ep.Passed
+			p.PerformedAction = ep.PerformedAction
+			p.Hand = ep.Hand
+			p.DrawPile = ep.DrawPile
+			p.DiscardPile = ep.DiscardPile
+		}
+	}
+
+	switch cp, np := g.CurrentPlayer(), g.PlayerByID(s.CurrentPlayerID); {
+	case np == nil:
+		g.setCurrentPlayers()
+	case cp == nil || !cp.Equal(np):
+		g.setCurrentPlayers(np)
+	}
+
+	if g.TempData == nil {
+		g.TempData = new(TempData)
+	}
+	g.PlayedCard = s.PlayedCard
+	g.JewelsPlayed = s.JewelsPlayed
+	g.Stepped = s.Stepped
+	g.BumpedPlayerID = s.BumpedPlayerID
+	g.SelectedThiefAreaF = nil
+	if a := s.SelectedThiefArea(); a != nil {
+		g.SelectedThiefAreaF = a
+	}
+	g.ClickAreas = nil
+}
+
+// apply applies action a to the game using the rules engine and logs the resulting events.
+func (g *Game) apply(ctx context.Context, a engine.Action) error {
+	s, es, err := engine.Apply(g.engineState(), a)
+	if err != nil {
+		return sn.NewVError("%v", err)
+	}
+
+	g.setEngineState(s)
+	for _, e := range es {
+		if le := g.logEvent(e); le != nil {
+			restful.AddNoticef(ctx, string(le.HTML(g)))
+		}
+	}
+	return nil
+}
+
+// logEvent adds a game log entry for the event e.
+func (g *Game) logEvent(e engine.Event) Entryer {
+	switch e := e.(type) {
+	case *engine.PlaceThiefEvent:
+		return g.newPlaceThiefEntry(e)
+	case *engine.PlayCardEvent:
+		return g.newPlayCardEntry(e)
+	case *engine.MoveThiefEvent:
+		return g.newMoveThiefEntry(e)
+	case *engine.ClaimItemEvent:
+		return g.newClaimItemEntry(e)
+	case *engine.DrawCardEvent:
+		return g.newDrawCardEntry(e)
+	case *engine.PassEvent:
+		return g.newPassEntry(e)
+	default:
+		return nil
+	}
+}
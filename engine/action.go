@@ -0,0 +1,299 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ActionType identifies the kind of an action.
+type ActionType int
+
+// Action types.
+const (
+	NoAction ActionType = iota
+	PlaceThief
+	PlayCard
+	SelectThief
+	MoveThief
+	Pass
+	FinishTurn
+)
+
+var actionTypeStrings = map[ActionType]string{
+	NoAction:    "None",
+	PlaceThief:  "Place Thief",
+	PlayCard:    "Play Card",
+	SelectThief: "Select Thief",
+	MoveThief:   "Move Thief",
+	Pass:        "Pass",
+	FinishTurn:  "Finish Turn",
+}
+
+func (t ActionType) String() string {
+	return actionTypeStrings[t]
+}
+
+// Action is a single step taken by a player.
+// Position is used by PlaceThief, SelectThief, and MoveThief.
+// Card is used by PlayCard.
+type Action struct {
+	Type     ActionType
+	PlayerID int
+	Position
+	Card CType
+}
+
+// ErrNotCurrentPlayer is returned when an action is taken by a player other than the current player.
+var ErrNotCurrentPlayer = errors.New("only the current player can perform an action")
+
+// Apply applies action a to state s.
+// It returns the resulting state and the events generated by the action.
+// s is not modified.  If the action is not permitted, s is returned together with an error.
+func Apply(s *State, a Action) (*State, []Event, error) {
+	if err := s.validateAction(a); err != nil {
+		return s, nil, err
+	}
+
+	ns := s.Clone()
+	var (
+		es  []Event
+		err error
+	)
+	switch a.Type {
+	case PlaceThief:
+		es, err = ns.placeThief(a)
+	case PlayCard:
+		es, err = ns.playCard(a)
+	case SelectThief:
+		es, err = ns.selectThief(a)
+	case MoveThief:
+		es, err = ns.moveThief(a)
+	case Pass:
+		es, err = ns.pass(a)
+	case FinishTurn:
+		es, err = ns.finishTurn(a)
+	default:
+		err = fmt.Errorf("%v is not a valid action", a.Type)
+	}
+
+	if err != nil {
+		return s, nil, err
+	}
+	return ns, es, nil
+}
+
+func (s *State) validateAction(a Action) error {
+	if cp := s.CurrentPlayer(); cp == nil || cp.ID != a.PlayerID {
+		return ErrNotCurrentPlayer
+	}
+	return nil
+}
+
+func (s *State) placeThief(a Action) ([]Event, error) {
+	cp := s.CurrentPlayer()
+	area := s.Grid.Area(a.Position)
+	switch {
+	case s.Phase != PhasePlaceThieves:
+		return nil, fmt.Errorf("expected %q phase but have %q phase", PhasePlaceThieves, s.Phase)
+	case cp.PerformedAction:
+		return nil, errors.New("you have already placed a thief")
+	case area == nil:
+		return nil, errors.New("you must select an area")
+	case !area.HasCard():
+		return nil, errors.New("you must select an area with a card")
+	case area.HasThief():
+		return nil, errors.New("you must select an area without a thief")
+	}
+
+	cp.PerformedAction = true
+	cp.Score += s.value(area.Card)
+	area.Thief = cp.ID
+
+	return []Event{&PlaceThiefEvent{EventBase: s.newEventBase(cp), Area: *area}}, nil
+}
+
+func (s *State) playCard(a Action) ([]Event, error) {
+	cp := s.CurrentPlayer()
+	i := cp.Hand.indexOf(a.Card)
+	switch {
+	case s.Phase != PhasePlayCard:
+		return nil, fmt.Errorf("expected %q phase but have %q phase", PhasePlayCard, s.Phase)
+	case cp.PerformedAction:
+		return nil, errors.New("you have already played a card")
+	case a.Card == NoType:
+		return nil, errors.New("you must select a card")
+	case i == -1:
+		return nil, fmt.Errorf("you don't have a %q card to play", a.Card)
+	case a.Card == Guard:
+		return nil, errors.New("a guard card cannot be played")
+	}
+
+	card := cp.Hand.playCardAt(i)
+	cp.DiscardPile = append(Cards{card}, cp.DiscardPile...)
+	if card.Type == Jewels {
+		pc := s.Jewels
+		s.PlayedCard = &pc
+		s.JewelsPlayed = true
+	} else {
+		s.PlayedCard = card
+	}
+
+	e := &PlayCardEvent{EventBase: s.newEventBase(cp), Type: card.Type}
+	s.Phase = PhaseSelectThief
+	return []Event{e}, nil
+}
+
+func (s *State) selectThief(a Action) ([]Event, error) {
+	cp := s.CurrentPlayer()
+	switch area := s.Grid.Area(a.Position); {
+	case s.Phase != PhaseSelectThief:
+		return nil, fmt.Errorf("expected %q phase but have %q phase", PhaseSelectThief, s.Phase)
+	case area == nil || area.Thief != cp.ID:
+		return nil, errors.New("you must select one of your thieves")
+	}
+
+	pos := a.Position
+	s.SelectedThief = &pos
+	s.Phase = PhaseMoveThief
+	return nil, nil
+}
+
+func (s *State) moveThief(a Action) ([]Event, error) {
+	cp := s.CurrentPlayer()
+	from, to := s.SelectedThiefArea(), s.Grid.Area(a.Position)
+	switch {
+	case s.Phase != PhaseMoveThief:
+		return nil, fmt.Errorf("expected %q phase but have %q phase", PhaseMoveThief, s.Phase)
+	case to == nil:
+		return nil, errors.New("you must select a space which to move your thief")
+	case from == nil || from.Thief != cp.ID:
+		return nil, errors.New("you must first select one of your thieves")
+	case !s.Destinations().Include(to):
+		return nil, fmt.Errorf("you can't move the selected thief to area %s%s", to.RowString(), to.ColString())
+	}
+
+	e := &MoveThiefEvent{
+		EventBase: s.newEventBase(cp),
+		Card:      *s.PlayedCard,
+		From:      *from,
+		To:        *to,
+	}
+	if s.JewelsPlayed {
+		e.Card = *newCard(Jewels, true)
+	}
+	es := []Event{e}
+
+	switch {
+	case s.PlayedCard.Type == Sword:
+		s.BumpedPlayerID = to.Thief
+		bumpedTo := s.bumpedTo(from, to)
+		bumpedTo.Thief = s.BumpedPlayerID
+		s.PlayerByID(s.BumpedPlayerID).Score += s.value(bumpedTo.Card) - s.value(to.Card)
+	case s.PlayedCard.Type == Turban && s.Stepped == 0:
+		s.Stepped = 1
+	case s.PlayedCard.Type == Turban && s.Stepped == 1:
+		s.Stepped = 2
+	}
+	to.Thief = cp.ID
+	cp.Score += s.value(to.Card)
+	return append(es, s.claimItem(from, to)...), nil
+}
+
+// BumpedTo returns the area to which a thief at to is bumped by a sword wielding thief moving from from.
+func (s *State) BumpedTo(from, to *Area) *Area {
+	return s.bumpedTo(from, to)
+}
+
+func (s *State) bumpedTo(from, to *Area) *Area {
+	switch {
+	case from.Row > to.Row:
+		return s.Grid[to.Row-1][from.Column]
+	case from.Row < to.Row:
+		return s.Grid[to.Row+1][from.Column]
+	case from.Column > to.Column:
+		return s.Grid[from.Row][to.Column-1]
+	case from.Column < to.Column:
+		return s.Grid[from.Row][to.Column+1]
+	default:
+		return nil
+	}
+}
+
+func (s *State) claimItem(from, to *Area) []Event {
+	cp := s.CurrentPlayer()
+	s.Phase = PhaseClaimItem
+	es := []Event{&ClaimItemEvent{EventBase: s.newEventBase(cp), Area: *from}}
+
+	card := from.Card
+	from.Card = nil
+	from.Thief = NoPID
+	switch {
+	case s.Turn == 1:
+		card.FaceUp = true
+		cp.Hand.append(card)
+		return append(es, s.drawCard()...)
+	case s.Stepped == 1:
+		cp.DiscardPile = append(Cards{card}, cp.DiscardPile...)
+		pos := to.Position()
+		s.SelectedThief = &pos
+		s.Phase = PhaseMoveThief
+		return es
+	default:
+		cp.DiscardPile = append(Cards{card}, cp.DiscardPile...)
+		return append(es, s.drawCard()...)
+	}
+}
+
+func (s *State) drawCard() (es []Event) {
+	s.Phase = PhaseDrawCard
+	cp := s.CurrentPlayer()
+
+	if s.Turn != 1 {
+		es = append(es, s.newDrawCardEvent(cp))
+		if s.PlayedCard.Type == Coins {
+			es = append(es, s.newDrawCardEvent(cp))
+		}
+	}
+	cp.PerformedAction = true
+	return
+}
+
+func (s *State) newDrawCardEvent(p *Player) *DrawCardEvent {
+	card, shuffle := p.draw(&s.Rand)
+	return &DrawCardEvent{
+		EventBase: s.newEventBase(p),
+		Card:      *card,
+		Shuffle:   shuffle,
+		RandPos:   s.Rand.Pos,
+	}
+}
+
+func (p *Player) draw(r *Rand) (*Card, bool) {
+	shuffle := false
+	if len(p.DrawPile) == 0 {
+		shuffle = true
+		p.DrawPile = p.DiscardPile
+		for _, card := range p.DrawPile {
+			card.FaceUp = false
+		}
+		p.DiscardPile = make(Cards, 0)
+	}
+	card := p.DrawPile.draw(r)
+	p.Hand.append(card)
+	return card, shuffle
+}
+
+func (s *State) pass(a Action) ([]Event, error) {
+	cp := s.CurrentPlayer()
+	switch {
+	case s.Phase != PhasePlayCard && s.Phase != PhaseSelectThief && s.Phase != PhaseMoveThief:
+		return nil, fmt.Errorf("you can't pass during the %q phase", s.Phase)
+	case cp.PerformedAction:
+		return nil, errors.New("you have already performed an action")
+	}
+
+	cp.Passed = true
+	cp.PerformedAction = true
+	s.Phase = PhaseDrawCard
+	return []Event{&PassEvent{EventBase: s.newEventBase(cp)}}, nil
+}
@@ -0,0 +1,232 @@
+package engine
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// playRandom plays a game of the players identified by pids, seeded with seed, to the end, choosing moves
+// with a random stream seeded by choices.  It returns the final state and the actions taken.
+func playRandom(t *testing.T, pids []int, twoThiefVariant bool, seed, choices int64) (*State, []Action) {
+	t.Helper()
+
+	s := New(pids, twoThiefVariant, seed)
+	r := NewRand(choices)
+	var as []Action
+	for turns := 0; s.Phase != PhaseGameOver; turns++ {
+		if turns > 10000 {
+			t.Fatal("the game did not end")
+		}
+
+		ms := LegalActions(s, s.CurrentPlayerID)
+		if len(ms) == 0 {
+			t.Fatalf("no legal moves in the %q phase", s.Phase)
+		}
+
+		m := RandomStrategy{}.Choose(s, s.CurrentPlayerID, ms, &r)
+		for _, a := range m.Actions {
+			var err error
+			if s, _, err = Apply(s, a); err != nil {
+				t.Fatalf("applying legal action %+v: %v", a, err)
+			}
+			as = append(as, a)
+		}
+	}
+	return s, as
+}
+
+// fingerprint returns the JSON encoding of s, by which states are compared.
+func fingerprint(t *testing.T, s *State) string {
+	t.Helper()
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// countCards returns the number of cards on the board and held by the players.
+func countCards(s *State) int {
+	n := 0
+	for _, row := range s.Grid {
+		for _, a := range row {
+			if a.HasCard() {
+				n++
+			}
+		}
+	}
+	for _, p := range s.Players {
+		n += len(p.Hand) + len(p.DrawPile) + len(p.DiscardPile)
+	}
+	return n
+}
+
+func TestApplyReplay(t *testing.T) {
+	tests := []struct {
+		name            string
+		pids            []int
+		twoThiefVariant bool
+		seed            int64
+	}{
+		{"two players", []int{1, 2}, false, 1},
+		{"three players", []int{1, 2, 3}, false, 2},
+		{"four players", []int{4, 3, 2, 1}, false, 3},
+		{"two thief variant", []int{1, 2}, true, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, as := playRandom(t, tt.pids, tt.twoThiefVariant, tt.seed, tt.seed+100)
+
+			s := New(tt.pids, tt.twoThiefVariant, tt.seed)
+			for _, a := range as {
+				var err error
+				if s, _, err = Apply(s, a); err != nil {
+					t.Fatalf("replaying %+v: %v", a, err)
+				}
+			}
+
+			if got, w := fingerprint(t, s), fingerprint(t, want); got != w {
+				t.Errorf("replay reached a different state:\ngot  %s\nwant %s", got, w)
+			}
+		})
+	}
+}
+
+func TestApplyLeavesStateUnmodified(t *testing.T) {
+	s := New([]int{1, 2, 3}, false, 5)
+	r := NewRand(6)
+	for s.Phase != PhaseGameOver {
+		before := fingerprint(t, s)
+		m := RandomStrategy{}.Choose(s, s.CurrentPlayerID, LegalActions(s, s.CurrentPlayerID), &r)
+
+		ns, err := Play(s, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if after := fingerprint(t, s); after != before {
+			t.Fatalf("playing %+v modified the state", m.Actions)
+		}
+		s = ns
+	}
+}
+
+func TestApplyRejectsOtherPlayers(t *testing.T) {
+	s := New([]int{1, 2, 3}, false, 7)
+	before := fingerprint(t, s)
+	for _, p := range s.Players {
+		if p.ID == s.CurrentPlayerID {
+			continue
+		}
+
+		for _, m := range LegalActions(s, s.CurrentPlayerID) {
+			a := m.Actions[0]
+			a.PlayerID = p.ID
+			ns, es, err := Apply(s, a)
+			if err != ErrNotCurrentPlayer {
+				t.Errorf("player %d taking %+v: got error %v, want %v", p.ID, a, err, ErrNotCurrentPlayer)
+			}
+			if ns != s || es != nil {
+				t.Errorf("player %d taking %+v changed the state", p.ID, a)
+			}
+		}
+	}
+	if fingerprint(t, s) != before {
+		t.Error("rejected actions modified the state")
+	}
+}
+
+func TestCloneIndependent(t *testing.T) {
+	s := New([]int{1, 2}, false, 8)
+	for s.Phase == PhasePlaceThieves {
+		var err error
+		if s, err = PlayTurn(s, LegalActions(s, s.CurrentPlayerID)[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pos := Position{Row: 0, Column: 0}
+	s.SelectedThief = &pos
+	s.PlayedCard = newCard(Sword, true)
+	before := fingerprint(t, s)
+
+	c := s.Clone()
+	if !reflect.DeepEqual(c, s) {
+		t.Fatal("the clone differs from the state")
+	}
+
+	c.Players[0].Score += 10
+	c.Players[0].Hand[0].FaceUp = !c.Players[0].Hand[0].FaceUp
+	c.Players[0].Hand = c.Players[0].Hand[1:]
+	c.Players[1].DiscardPile = append(c.Players[1].DiscardPile, newCard(Coins, true))
+	c.Grid[0][0].Thief = NoPID
+	c.Grid[1][1].Card = nil
+	c.SelectedThief.Row = 3
+	c.PlayedCard.Type = Coins
+	c.Rand.Uint64()
+
+	if after := fingerprint(t, s); after != before {
+		t.Errorf("modifying the clone modified the state:\ngot  %s\nwant %s", after, before)
+	}
+}
+
+func TestPlaceThiefScores(t *testing.T) {
+	s := New([]int{1, 2}, false, 9)
+	for _, row := range s.Grid {
+		for _, a := range row {
+			if !a.HasCard() {
+				continue
+			}
+
+			pid := s.CurrentPlayerID
+			ns, _, err := Apply(s, Action{Type: PlaceThief, PlayerID: pid, Position: a.Position()})
+			if err != nil {
+				t.Fatalf("placing a thief at %s: %v", a.Position().Label(), err)
+			}
+			if got, want := ns.PlayerByID(pid).Score, s.PlayerByID(pid).Score+s.value(a.Card); got != want {
+				t.Errorf("placing a thief on %s at %s: got score %d, want %d", a.Card.Type, a.Position().Label(), got, want)
+			}
+		}
+	}
+}
+
+func TestEndOfGame(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		pids := []int{1, 2, 3, 4}[:2+seed%3]
+		s := New(pids, seed%2 == 1, seed)
+		cards := countCards(s)
+
+		final, _ := playRandom(t, pids, seed%2 == 1, seed, seed)
+		switch {
+		case final.CurrentPlayerID != NoPID:
+			t.Errorf("seed %d: the game ended with current player %d", seed, final.CurrentPlayerID)
+		case countCards(final) != cards:
+			t.Errorf("seed %d: the game ended with %d cards, but began with %d", seed, countCards(final), cards)
+		case LegalActions(final, pids[0]) != nil:
+			t.Errorf("seed %d: moves remain after the game ended", seed)
+		}
+
+		for _, row := range final.Grid {
+			for _, a := range row {
+				if a.HasThief() {
+					t.Errorf("seed %d: a thief remains at %s after the final claim", seed, a.Position().Label())
+				}
+			}
+		}
+
+		for _, p := range final.Players {
+			if len(p.DrawPile) != 0 || len(p.DiscardPile) != 0 {
+				t.Errorf("seed %d: player %d kept cards outside the hand after the final claim", seed, p.ID)
+			}
+			for _, c := range p.Hand {
+				if !c.FaceUp {
+					t.Errorf("seed %d: player %d holds a face down card after the final claim", seed, p.ID)
+				}
+			}
+			if p.Score < 0 {
+				t.Errorf("seed %d: player %d has negative score %d", seed, p.ID, p.Score)
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package engine
+
+import "strconv"
+
+// Areas is a slice of grid areas.
+type Areas []*Area
+
+// Grid is the rows of areas forming the board.
+type Grid []Areas
+
+const (
+	rowA int = iota
+	rowB
+	rowC
+	noRow int = -1
+)
+
+// rowLabel returns the label of the row: A through Z, followed by AA, AB and so on.
+func rowLabel(row int) string {
+	if row < 0 {
+		return "None"
+	}
+
+	var label []byte
+	for n := row + 1; n > 0; n = (n - 1) / 26 {
+		label = append([]byte{byte('A' + (n-1)%26)}, label...)
+	}
+	return string(label)
+}
+
+// RowString outputs a row label.
+func (a *Area) RowString() string {
+	return rowLabel(a.Row)
+}
+
+// RowIDString outputs an row id.
+func (a *Area) RowIDString() string {
+	return strconv.Itoa(a.Row)
+}
+
+const (
+	col1 int = iota
+	col2
+	col3
+	noCol int = -1
+)
+
+// columnLabel returns the label of the column, numbering columns from 1.
+func columnLabel(col int) string {
+	if col < 0 {
+		return "None"
+	}
+	return strconv.Itoa(col + 1)
+}
+
+// ColString outputs a column label.
+func (a *Area) ColString() string {
+	return columnLabel(a.Column)
+}
+
+// ColIDString outputs an column id.
+func (a *Area) ColIDString() string {
+	return strconv.Itoa(a.Column)
+}
+
+// Area of the grid.
+type Area struct {
+	Row    int
+	Column int
+	Thief  int
+	Card   *Card
+	// Blocked indicates the area is not part of the board.  No thief may enter or cross it.
+	Blocked bool
+}
+
+// Position identifies an area of the grid by row and column.
+type Position struct {
+	Row    int
+	Column int
+}
+
+// Label outputs the row and column labels of the position.
+func (p Position) Label() string {
+	return rowLabel(p.Row) + columnLabel(p.Column)
+}
+
+// Position returns the position of the area.
+func (a *Area) Position() Position {
+	return Position{Row: a.Row, Column: a.Column}
+}
+
+func newArea(row, col int, card *Card) *Area {
+	return &Area{
+		Row:    row,
+		Column: col,
+		Thief:  NoPID,
+		Card:   card,
+	}
+}
+
+// newGrid deals the grid described by layout l from deck.
+func newGrid(l *Layout, deck Cards, r *Rand) Grid {
+	g := make(Grid, l.Rows)
+	for row := range g {
+		g[row] = make(Areas, l.Columns)
+		for col := range g[row] {
+			switch p := (Position{Row: row, Column: col}); {
+			case l.isBlocked(p):
+				a := newArea(row, col, nil)
+				a.Blocked = true
+				g[row][col] = a
+			case l.isEmpty(p):
+				g[row][col] = newArea(row, col, nil)
+			default:
+				g[row][col] = newArea(row, col, deck.draw(r))
+			}
+		}
+	}
+	return g
+}
+
+// HasThief indicates whether a thief occupies the area.
+func (a *Area) HasThief() bool {
+	return a.Thief != NoPID
+}
+
+// HasCard indicates whether a card remains in the area.
+func (a *Area) HasCard() bool {
+	return a.Card != nil
+}
+
+// Include indicates whether an area at the same position as a2 is in as.
+func (as Areas) Include(a2 *Area) bool {
+	for _, a1 := range as {
+		if a1.Row == a2.Row && a1.Column == a2.Column {
+			return true
+		}
+	}
+	return false
+}
+
+// Area returns the area at position p, or nil if p is off the grid.
+func (g Grid) Area(p Position) *Area {
+	if p.Row < 0 || p.Row >= len(g) || p.Column < 0 || p.Column >= len(g[p.Row]) {
+		return nil
+	}
+	return g[p.Row][p.Column]
+}
+
+func (g Grid) clone() Grid {
+	if g == nil {
+		return nil
+	}
+	c := make(Grid, len(g))
+	for row, as := range g {
+		c[row] = make(Areas, len(as))
+		for col, a := range as {
+			area := *a
+			if a.Card != nil {
+				card := *a.Card
+				area.Card = &card
+			}
+			c[row][col] = &area
+		}
+	}
+	return c
+}
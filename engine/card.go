@@ -0,0 +1,249 @@
+package engine
+
+import "strings"
+
+// CType identifies the type of a card.
+type CType int
+
+// Card types.
+const (
+	NoType CType = iota
+	Lamp
+	Camel
+	Sword
+	Carpet
+	Coins
+	Turban
+	Jewels
+	Guard
+	StartCamel
+	StartLamp
+)
+
+// CardTypes returns all card types used by the game.
+func CardTypes() []CType {
+	return []CType{
+		Lamp,
+		Camel,
+		Sword,
+		Carpet,
+		Coins,
+		Turban,
+		Jewels,
+		Guard,
+		StartCamel,
+		StartLamp,
+	}
+}
+
+var ctypeStrings = map[CType]string{
+	NoType:     "None",
+	Lamp:       "Lamp",
+	Camel:      "Camel",
+	Sword:      "Sword",
+	Carpet:     "Carpet",
+	Coins:      "Coins",
+	Turban:     "Turban",
+	Jewels:     "Jewels",
+	Guard:      "Guard",
+	StartCamel: "Camel",
+	StartLamp:  "Lamp",
+}
+
+var stringsCType = map[string]CType{
+	"none":        NoType,
+	"lamp":        Lamp,
+	"camel":       Camel,
+	"sword":       Sword,
+	"carpet":      Carpet,
+	"coins":       Coins,
+	"turban":      Turban,
+	"jewels":      Jewels,
+	"guard":       Guard,
+	"start-camel": StartCamel,
+	"start-lamp":  StartLamp,
+}
+
+// ToCType returns the card type having the provided id string.
+func ToCType(s string) (t CType) {
+	s = strings.ToLower(s)
+
+	var ok bool
+	if t, ok = stringsCType[s]; !ok {
+		t = NoType
+	}
+	return
+}
+
+var ctypeValues = map[CType]int{
+	NoType:     0,
+	Lamp:       1,
+	Camel:      4,
+	Sword:      5,
+	Carpet:     3,
+	Coins:      3,
+	Turban:     2,
+	Jewels:     2,
+	Guard:      -1,
+	StartCamel: 0,
+	StartLamp:  0,
+}
+
+func (t CType) String() string {
+	return ctypeStrings[t]
+}
+
+// LString outputs a lower case card type label.
+func (t CType) LString() string {
+	return strings.ToLower(t.String())
+}
+
+// IDString outputs a card type id.
+func (t CType) IDString() string {
+	switch t {
+	case StartCamel:
+		return "start-camel"
+	case StartLamp:
+		return "start-lamp"
+	default:
+		return t.LString()
+	}
+}
+
+// Card is a playing card used to form grid, player's hand, and player's deck.
+type Card struct {
+	Type   CType
+	FaceUp bool
+}
+
+func newCard(t CType, f bool) *Card {
+	return &Card{
+		Type:   t,
+		FaceUp: f,
+	}
+}
+
+// Cards is a slice of cards used to form player's hand or deck.
+type Cards []*Card
+
+func (cs Cards) removeAt(i int) Cards {
+	return append(cs[:i], cs[i+1:]...)
+}
+
+func (cs *Cards) playCardAt(i int) *Card {
+	card := (*cs)[i]
+	*cs = cs.removeAt(i)
+	return card
+}
+
+func (cs *Cards) draw(r *Rand) *Card {
+	var card *Card
+	*cs, card = cs.drawS(r)
+	return card
+}
+
+func (cs Cards) drawS(r *Rand) (Cards, *Card) {
+	i := r.Intn(len(cs))
+	card := cs[i]
+	cards := cs.removeAt(i)
+	return cards, card
+}
+
+func (cs *Cards) append(cards ...*Card) {
+	*cs = cs.appendS(cards...)
+}
+
+func (cs Cards) appendS(cards ...*Card) Cards {
+	if len(cards) == 0 {
+		return cs
+	}
+	return append(cs, cards...)
+}
+
+func (cs Cards) indexOf(t CType) int {
+	for i, c := range cs {
+		if c.Type == t {
+			return i
+		}
+	}
+	return -1
+}
+
+func (cs Cards) clone() Cards {
+	if cs == nil {
+		return nil
+	}
+	cards := make(Cards, len(cs))
+	for i, c := range cs {
+		card := *c
+		cards[i] = &card
+	}
+	return cards
+}
+
+// CountFor provides the number of faceUp and faceDown cards of type t.
+func (cs Cards) CountFor(t CType) (faceUp, faceDown int) {
+	for _, c := range cs {
+		switch {
+		case c.Type == t && c.FaceUp:
+			faceUp++
+		case c.Type == t && !c.FaceUp:
+			faceDown++
+		}
+	}
+	return
+}
+
+// LampCount provides the number of lamps among the cards.
+func LampCount(cs ...*Card) (count int) {
+	for _, c := range cs {
+		if c.Type == Lamp || c.Type == StartLamp {
+			count++
+		}
+	}
+	return count
+}
+
+// CamelCount provides the number of camels among the cards.
+func CamelCount(cs ...*Card) (count int) {
+	for _, c := range cs {
+		if c.Type == Camel || c.Type == StartCamel {
+			count++
+		}
+	}
+	return count
+}
+
+// IDString outputs a card id.
+func (c Card) IDString() string {
+	return c.Type.IDString()
+}
+
+// NewStartHand returns the cards each player begins the game with under the standard ruleset.
+func NewStartHand() Cards {
+	return standardRuleset.startHand()
+}
+
+var toolTipStrings = map[CType]string{
+	NoType:     "None",
+	Lamp:       "Move in a straight line until coming to the edge of the grid, an empty space, or another Thief.",
+	Camel:      "Move exactly 3 spaces in any direction. The spaces do not have to be in a straight line, but you cannot move over the same space twice.",
+	Sword:      "Move in a straight line until you come to another player's thief. Bump that thief to the next card and place your thief on the vacated card.",
+	Carpet:     "Move in a straight line over at least one empty space.  Stop moving your thief on the first card after the empty space(s).",
+	Coins:      "Move one space and then draw an additional card during the draw step. Your hand size is permanently increased by 1.",
+	Turban:     "Move two spaces. Claim the first Magic Item you pass over in addition to the card you claim in the Claim Magic Item step.",
+	Jewels:     "Move as if you played the card that was last played by an opponent.",
+	Guard:      "This card cannot be played and does nothing for you in your hand.",
+	StartCamel: "Move exactly 3 spaces in any direction. The spaces do not have to be in a straight line, but you cannot move over the same space twice.",
+	StartLamp:  "Move in a straight line until coming to the edge of the grid, an empty space, or another Thief.",
+}
+
+// ToolTip outputs a description of the cards ability.
+func (c Card) ToolTip() string {
+	return c.Type.ToolTip()
+}
+
+// ToolTip outputs a description of the card type's ability.
+func (t CType) ToolTip() string {
+	return toolTipStrings[t]
+}
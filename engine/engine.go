@@ -0,0 +1,187 @@
+// Package engine implements the rules of Guild of Thieves independent of any
+// web framework, datastore, or cache, so that games may be driven directly by
+// bots, simulators, and tests.
+package engine
+
+// NoPID indicates the absence of a player.
+const NoPID = -1
+
+// Player stores the rules relevant state of one of the players of the game.
+type Player struct {
+	ID              int
+	Score           int
+	Passed          bool
+	PerformedAction bool
+	Hand            Cards
+	DrawPile        Cards
+	DiscardPile     Cards
+}
+
+func newPlayer(id int, hand Cards) *Player {
+	return &Player{
+		ID:          id,
+		Hand:        hand,
+		DrawPile:    make(Cards, 0),
+		DiscardPile: make(Cards, 0),
+	}
+}
+
+func (p *Player) clone() *Player {
+	c := *p
+	c.Hand = p.Hand.clone()
+	c.DrawPile = p.DrawPile.clone()
+	c.DiscardPile = p.DiscardPile.clone()
+	return &c
+}
+
+// State stores everything needed to apply the rules of the game.
+type State struct {
+	// Players are listed in turn order.
+	Players         []*Player
+	Grid            Grid
+	Jewels          Card
+	TwoThiefVariant bool
+	Phase           Phase
+	Turn            int
+	Round           int
+	CurrentPlayerID int
+	// Rand provides every random outcome of the game, so that the game may be
+	// reproduced from its seed and the actions taken.
+	Rand Rand
+	// Ruleset defines the cards of the game.  Nil indicates the standard ruleset.
+	Ruleset *Ruleset
+	// Teams lists the player ids of the members of each team.
+	// Nil indicates every player plays alone.  Teams must not be modified once play begins.
+	Teams [][]int
+	// LastTurn is the last turn of the game.  Zero indicates play continues until every player passes.
+	LastTurn int
+
+	// The following track the progress of the current player's turn.
+	PlayedCard     *Card
+	JewelsPlayed   bool
+	SelectedThief  *Position
+	Stepped        int
+	BumpedPlayerID int
+}
+
+// New returns the state of a newly setup game, played under the standard ruleset, awaiting placement of thieves.
+// The players are identified by pids, listed in turn order.
+// The grid and all subsequent draws are determined by seed.
+func New(pids []int, twoThiefVariant bool, seed int64) *State {
+	return NewWithRuleset(pids, twoThiefVariant, nil, seed)
+}
+
+// NewWithRuleset returns the state of a newly setup game played under ruleset rs, awaiting placement of thieves.
+// A nil ruleset indicates the standard ruleset.
+func NewWithRuleset(pids []int, twoThiefVariant bool, rs *Ruleset, seed int64) *State {
+	s := &State{
+		Players:         make([]*Player, len(pids)),
+		TwoThiefVariant: twoThiefVariant,
+		Phase:           PhasePlaceThieves,
+		BumpedPlayerID:  NoPID,
+		Rand:            NewRand(seed),
+		Ruleset:         rs,
+	}
+	s.Grid = newGrid(s.ruleset().layoutFor(len(pids)), s.ruleset().deckFor(len(pids)), &s.Rand)
+	for i, pid := range pids {
+		s.Players[i] = newPlayer(pid, s.ruleset().startHand())
+	}
+	s.CurrentPlayerID = s.previousPlayer(s.Players[0]).ID
+	return s
+}
+
+// Clone returns a deep copy of the state.
+func (s *State) Clone() *State {
+	c := *s
+	c.Players = make([]*Player, len(s.Players))
+	for i, p := range s.Players {
+		c.Players[i] = p.clone()
+	}
+	c.Grid = s.Grid.clone()
+	if s.PlayedCard != nil {
+		card := *s.PlayedCard
+		c.PlayedCard = &card
+	}
+	if s.SelectedThief != nil {
+		pos := *s.SelectedThief
+		c.SelectedThief = &pos
+	}
+	return &c
+}
+
+// PlayerByID returns the player having the provided player id.
+func (s *State) PlayerByID(id int) *Player {
+	for _, p := range s.Players {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// CurrentPlayer returns the player whose turn it is.
+func (s *State) CurrentPlayer() *Player {
+	return s.PlayerByID(s.CurrentPlayerID)
+}
+
+// SelectedThiefArea returns the area of the thief selected to move.
+func (s *State) SelectedThiefArea() *Area {
+	if s.SelectedThief == nil {
+		return nil
+	}
+	return s.Grid.Area(*s.SelectedThief)
+}
+
+func (s *State) indexOf(p *Player) int {
+	for i, p2 := range s.Players {
+		if p2.ID == p.ID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *State) nextPlayer(p *Player) *Player {
+	return s.Players[(s.indexOf(p)+1)%len(s.Players)]
+}
+
+func (s *State) previousPlayer(p *Player) *Player {
+	l := len(s.Players)
+	return s.Players[(s.indexOf(p)+l-1)%l]
+}
+
+func (s *State) allPassed() bool {
+	for _, p := range s.Players {
+		if !p.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *State) lastRow() int {
+	return len(s.Grid) - 1
+}
+
+func (s *State) lastCol() int {
+	return len(s.Grid[0]) - 1
+}
+
+func (s *State) numThieves() int {
+	return thievesPerPlayer(s.TwoThiefVariant)
+}
+
+func thievesPerPlayer(twoThiefVariant bool) int {
+	if twoThiefVariant {
+		return 2
+	}
+	return 3
+}
+
+func (s *State) resetTurn() {
+	s.PlayedCard = nil
+	s.JewelsPlayed = false
+	s.SelectedThief = nil
+	s.Stepped = 0
+	s.BumpedPlayerID = NoPID
+}
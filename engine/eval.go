@@ -0,0 +1,96 @@
+package engine
+
+// Weights of the features of a player's position other than score.
+// Ties in score are broken by lamps, then camels, then the number of cards,
+// so those are weighted in that order, each well below a point of score.
+// Cards other than guards also add to the moves available in later turns,
+// so each is additionally weighted as deck growth.
+const (
+	lampWeight  = 0.04
+	camelWeight = 0.02
+	cardWeight  = 0.01
+	deckWeight  = 0.15
+)
+
+// Evaluate returns the value of state s to the player identified by pid:
+// the worth of the player's side less the worth of the best opposing side.
+// A side is a team in a team game, and a single player otherwise.
+// Only information visible to every player is considered, as the composition of
+// each player's cards is public even though their order is not.
+func Evaluate(s *State, pid int) float64 {
+	if s.PlayerByID(pid) == nil {
+		return 0
+	}
+
+	var v, best float64
+	opposed := false
+	for _, side := range s.sides() {
+		w := 0.0
+		for _, p := range side {
+			w += worth(p)
+		}
+
+		switch {
+		case len(side) > 0 && s.Partners(pid, side[0].ID):
+			v = w
+		case !opposed || w > best:
+			best, opposed = w, true
+		}
+	}
+	return v - best
+}
+
+// worth returns the score of the player together with the tiebreaks and deck growth of the player's cards.
+// A player's cards are counted wherever they are, as the final claim returns them all to the hand.
+func worth(p *Player) float64 {
+	cards := make(Cards, 0, len(p.Hand)+len(p.DrawPile)+len(p.DiscardPile))
+	cards = append(append(append(cards, p.Hand...), p.DrawPile...), p.DiscardPile...)
+
+	playable := 0
+	for _, card := range cards {
+		if card.Type != Guard {
+			playable++
+		}
+	}
+
+	return float64(p.Score) +
+		lampWeight*float64(LampCount(cards...)) +
+		camelWeight*float64(CamelCount(cards...)) +
+		cardWeight*float64(len(cards)) +
+		deckWeight*float64(playable)
+}
+
+// Play applies the actions of move m to state s, returning the resulting state.
+// s is not modified.
+func Play(s *State, m Move) (*State, error) {
+	ns := s
+	for _, a := range m.Actions {
+		var err error
+		if ns, _, err = Apply(ns, a); err != nil {
+			return s, err
+		}
+	}
+	return ns, nil
+}
+
+// PlayTurn applies move m to state s and, if the move leaves only the end of the turn, finishes the turn.
+// s is not modified.
+func PlayTurn(s *State, m Move) (*State, error) {
+	ns, err := Play(s, m)
+	if err != nil {
+		return s, err
+	}
+
+	cp := ns.CurrentPlayer()
+	if cp == nil || cp.ID != s.CurrentPlayerID {
+		return ns, nil
+	}
+	if ms := LegalActions(ns, cp.ID); len(ms) == 1 && isFinishTurn(ms[0]) {
+		return Play(ns, ms[0])
+	}
+	return ns, nil
+}
+
+func isFinishTurn(m Move) bool {
+	return len(m.Actions) == 1 && m.Actions[0].Type == FinishTurn
+}
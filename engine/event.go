@@ -0,0 +1,69 @@
+package engine
+
+// Event records a change to the state resulting from applying an action.
+type Event interface {
+	Base() *EventBase
+}
+
+// EventBase stores information common to all events.
+type EventBase struct {
+	PlayerID int
+	Phase    Phase
+	Turn     int
+	Round    int
+}
+
+// Base returns the information common to all events.
+func (e *EventBase) Base() *EventBase {
+	return e
+}
+
+func (s *State) newEventBase(p *Player) EventBase {
+	return EventBase{
+		PlayerID: p.ID,
+		Phase:    s.Phase,
+		Turn:     s.Turn,
+		Round:    s.Round,
+	}
+}
+
+// PlaceThiefEvent records the placement of a thief.
+type PlaceThiefEvent struct {
+	EventBase
+	Area Area
+}
+
+// PlayCardEvent records the play of a card.
+type PlayCardEvent struct {
+	EventBase
+	Type CType
+}
+
+// MoveThiefEvent records the movement of a thief.
+type MoveThiefEvent struct {
+	EventBase
+	Card Card
+	From Area
+	To   Area
+}
+
+// ClaimItemEvent records the claim of the card vacated by a thief.
+type ClaimItemEvent struct {
+	EventBase
+	Area Area
+}
+
+// DrawCardEvent records the draw of a card, including whether the discard pile
+// was first shuffled to form a new draw pile and the position of the random
+// stream following the draw.
+type DrawCardEvent struct {
+	EventBase
+	Card    Card
+	Shuffle bool
+	RandPos uint64
+}
+
+// PassEvent records a player passing.
+type PassEvent struct {
+	EventBase
+}
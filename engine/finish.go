@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+func (s *State) finishTurn(a Action) ([]Event, error) {
+	cp := s.CurrentPlayer()
+	if !cp.PerformedAction {
+		return nil, errors.New("you have yet to perform an action")
+	}
+
+	switch s.Phase {
+	case PhasePlaceThieves:
+		s.placeThievesFinishTurn()
+	case PhaseDrawCard:
+		s.moveThiefFinishTurn()
+	default:
+		return nil, fmt.Errorf("you can't finish your turn during the %q phase", s.Phase)
+	}
+	return nil, nil
+}
+
+func (s *State) placeThievesNextPlayer() *Player {
+	p := s.previousPlayer(s.CurrentPlayer())
+	if s.Round >= s.numThieves() {
+		return nil
+	}
+	if p.ID == s.Players[0].ID {
+		s.Round++
+	}
+	return p
+}
+
+func (s *State) placeThievesFinishTurn() {
+	np := s.placeThievesNextPlayer()
+	if np == nil {
+		np = s.Players[0]
+		s.Phase = PhasePlayCard
+		s.Turn = 1
+	}
+	s.CurrentPlayerID = np.ID
+	np.beginningOfTurnReset()
+}
+
+func (s *State) endOfTurnUpdateFor(p *Player) {
+	if s.PlayedCard != nil {
+		s.Jewels = *(s.PlayedCard)
+	}
+
+	for _, card := range p.Hand {
+		card.FaceUp = true
+	}
+}
+
+func (s *State) moveThiefNextPlayer() *Player {
+	cp := s.CurrentPlayer()
+	s.endOfTurnUpdateFor(cp)
+	np := s.nextPlayer(cp)
+	for !s.allPassed() {
+		if np.Passed {
+			np = s.nextPlayer(np)
+		} else {
+			np.beginningOfTurnReset()
+			return np
+		}
+	}
+	return nil
+}
+
+func (s *State) moveThiefFinishTurn() {
+	np := s.moveThiefNextPlayer()
+	s.resetTurn()
+
+	// If no next player, or the last turn is over, end game
+	if np == nil || (s.LastTurn != 0 && np.ID == s.Players[0].ID && s.Turn >= s.LastTurn) {
+		s.finalClaim()
+		s.CurrentPlayerID = NoPID
+		s.Phase = PhaseGameOver
+		return
+	}
+
+	// Otherwise, select next player and continue moving theives.
+	s.CurrentPlayerID = np.ID
+	if np.ID == s.Players[0].ID {
+		s.Turn++
+	}
+	s.Phase = PhasePlayCard
+}
+
+func (s *State) finalClaim() {
+	s.Phase = PhaseFinalClaim
+	for _, row := range s.Grid {
+		for _, a := range row {
+			if p := s.PlayerByID(a.Thief); p != nil {
+				card := a.Card
+				a.Card = nil
+				a.Thief = NoPID
+				p.DiscardPile = append(Cards{card}, p.DiscardPile...)
+			}
+		}
+	}
+	for _, p := range s.Players {
+		p.Hand.append(p.DiscardPile...)
+		p.Hand.append(p.DrawPile...)
+		for _, card := range p.Hand {
+			card.FaceUp = true
+		}
+		p.DiscardPile, p.DrawPile = make(Cards, 0), make(Cards, 0)
+	}
+}
+
+func (p *Player) beginningOfTurnReset() {
+	p.PerformedAction = false
+}
@@ -0,0 +1,40 @@
+package engine
+
+import "sort"
+
+// RankedMove is a legal move together with its evaluation for the player making it.
+type RankedMove struct {
+	Move
+	// Points is the gain in score from the move.
+	Points int
+	// Value is the gain in value, as given by Evaluate, from the move.
+	Value float64
+}
+
+// Rank returns the legal moves ms of the player identified by pid in state s, ordered from the greatest
+// gain in value to the least.  Moves of equal value keep their order in ms.
+// The value of a position does not depend on which cards are drawn, so the ranking reveals nothing of
+// the cards the player will draw.
+func Rank(s *State, pid int, ms []Move) []RankedMove {
+	p := s.PlayerByID(pid)
+	if p == nil {
+		return nil
+	}
+
+	v := Evaluate(s, pid)
+	rms := make([]RankedMove, 0, len(ms))
+	for _, m := range ms {
+		ns, err := PlayTurn(s, m)
+		if err != nil {
+			continue
+		}
+		rms = append(rms, RankedMove{
+			Move:   m,
+			Points: ns.PlayerByID(pid).Score - p.Score,
+			Value:  Evaluate(ns, pid) - v,
+		})
+	}
+
+	sort.SliceStable(rms, func(i, j int) bool { return rms[i].Value > rms[j].Value })
+	return rms
+}
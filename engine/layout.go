@@ -0,0 +1,104 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Limits of the dimensions of a board.
+const (
+	minDimension = 3
+	maxDimension = 26
+)
+
+// Layout defines the board: its dimensions and the squares that begin the game without a card.
+type Layout struct {
+	Name    string
+	Rows    int
+	Columns int
+	// Empty lists squares dealt no card.  As with squares emptied during play,
+	// a thief may not stop on them but may cross them by carpet.
+	Empty []Position
+	// Blocked lists squares that are not part of the board.  No thief may enter or cross them.
+	Blocked []Position
+}
+
+// Limits of the number of players of a game.
+const (
+	MinPlayers = 2
+	MaxPlayers = 6
+)
+
+// DefaultLayout returns the layout of the board for numPlayers players.
+// The published game uses eight columns of six rows for two players, and of seven rows for three or four.
+// Five players use nine columns of eight rows, and six players nine columns of nine rows.
+func DefaultLayout(numPlayers int) *Layout {
+	switch {
+	case numPlayers <= 2:
+		return &Layout{Name: "standard", Rows: 6, Columns: 8}
+	case numPlayers <= 4:
+		return &Layout{Name: "standard", Rows: 7, Columns: 8}
+	case numPlayers == 5:
+		return &Layout{Name: "standard", Rows: 8, Columns: 9}
+	default:
+		return &Layout{Name: "standard", Rows: 9, Columns: 9}
+	}
+}
+
+// Squares returns the number of squares dealt a card.
+func (l *Layout) Squares() int {
+	n := 0
+	for row := 0; row < l.Rows; row++ {
+		for col := 0; col < l.Columns; col++ {
+			if p := (Position{Row: row, Column: col}); !l.isEmpty(p) && !l.isBlocked(p) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Validate returns an error if the layout cannot be used for a game of numPlayers players
+// each placing numThieves thieves.
+func (l *Layout) Validate(numPlayers, numThieves int) error {
+	switch {
+	case l.Rows < minDimension || l.Rows > maxDimension:
+		return fmt.Errorf("a board must have from %d to %d rows, but has %d", minDimension, maxDimension, l.Rows)
+	case l.Columns < minDimension || l.Columns > maxDimension:
+		return fmt.Errorf("a board must have from %d to %d columns, but has %d", minDimension, maxDimension, l.Columns)
+	}
+
+	for _, ps := range [][]Position{l.Empty, l.Blocked} {
+		for _, p := range ps {
+			if !l.contains(p) {
+				return fmt.Errorf("square %s is off the board", p.Label())
+			}
+		}
+	}
+
+	if l.Squares() < numPlayers*numThieves {
+		return errors.New("the board has too few squares for every thief to be placed")
+	}
+	return nil
+}
+
+func (l *Layout) contains(p Position) bool {
+	return p.Row >= 0 && p.Row < l.Rows && p.Column >= 0 && p.Column < l.Columns
+}
+
+func (l *Layout) isEmpty(p Position) bool {
+	return includes(l.Empty, p)
+}
+
+func (l *Layout) isBlocked(p Position) bool {
+	return includes(l.Blocked, p)
+}
+
+func includes(ps []Position, p Position) bool {
+	for _, q := range ps {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
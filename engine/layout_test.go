@@ -0,0 +1,89 @@
+package engine
+
+import "testing"
+
+func TestLayoutValidate(t *testing.T) {
+	corners := []Position{{0, 0}, {0, 2}, {2, 0}, {2, 2}, {1, 1}}
+
+	tests := []struct {
+		name       string
+		l          *Layout
+		numPlayers int
+		numThieves int
+		valid      bool
+	}{
+		{"standard for two", DefaultLayout(2), 2, 3, true},
+		{"standard for four", DefaultLayout(4), 4, 3, true},
+		{"standard for five", DefaultLayout(5), 5, 3, true},
+		{"standard for six", DefaultLayout(6), 6, 3, true},
+		{"smallest", &Layout{Rows: minDimension, Columns: minDimension}, 3, 3, true},
+		{"largest", &Layout{Rows: maxDimension, Columns: maxDimension}, 6, 3, true},
+		{"too few rows", &Layout{Rows: minDimension - 1, Columns: 8}, 2, 3, false},
+		{"too many rows", &Layout{Rows: maxDimension + 1, Columns: 8}, 2, 3, false},
+		{"too few columns", &Layout{Rows: 6, Columns: minDimension - 1}, 2, 3, false},
+		{"too many columns", &Layout{Rows: 6, Columns: maxDimension + 1}, 2, 3, false},
+		{"empty square off the board", &Layout{Rows: 6, Columns: 8, Empty: []Position{{6, 0}}}, 2, 3, false},
+		{"blocked square off the board", &Layout{Rows: 6, Columns: 8, Blocked: []Position{{0, -1}}}, 2, 3, false},
+		{"blocked leaving room for every thief", &Layout{Rows: 3, Columns: 3, Blocked: corners}, 2, 2, true},
+		{"blocked leaving too few open squares", &Layout{Rows: 3, Columns: 3, Blocked: corners}, 2, 3, false},
+		{"emptied leaving too few squares", &Layout{Rows: 3, Columns: 3, Empty: corners}, 3, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.l.Validate(tt.numPlayers, tt.numThieves); (err == nil) != tt.valid {
+				t.Errorf("got error %v, want valid %t", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestLayoutSquares(t *testing.T) {
+	tests := []struct {
+		name string
+		l    *Layout
+		want int
+	}{
+		{"standard for two", DefaultLayout(2), 48},
+		{"standard for four", DefaultLayout(4), 56},
+		{"standard for five", DefaultLayout(5), 72},
+		{"standard for six", DefaultLayout(6), 81},
+		{"empty and blocked", &Layout{Rows: 6, Columns: 8, Empty: []Position{{0, 0}, {5, 7}}, Blocked: []Position{{2, 3}}}, 45},
+		{"both empty and blocked", &Layout{Rows: 6, Columns: 8, Empty: []Position{{2, 3}}, Blocked: []Position{{2, 3}}}, 47},
+	}
+
+	for _, tt := range tests {
+		if got := tt.l.Squares(); got != tt.want {
+			t.Errorf("%s: got %d squares, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLayoutFor(t *testing.T) {
+	tests := []struct {
+		numPlayers int
+		rows       int
+		columns    int
+	}{
+		{2, 6, 8},
+		{3, 7, 8},
+		{4, 7, 8},
+		{5, 8, 9},
+		{6, 9, 9},
+	}
+
+	custom := &Layout{Name: "custom", Rows: 5, Columns: 5, Blocked: []Position{{2, 2}}}
+	for _, tt := range tests {
+		l := testRuleset().layoutFor(tt.numPlayers)
+		if l.Rows != tt.rows || l.Columns != tt.columns {
+			t.Errorf("%d players: got a board of %d rows and %d columns, want %d and %d",
+				tt.numPlayers, l.Rows, l.Columns, tt.rows, tt.columns)
+		}
+
+		rs := testRuleset()
+		rs.Layout = custom
+		if l := rs.layoutFor(tt.numPlayers); l != custom {
+			t.Errorf("%d players: got layout %+v, want the ruleset's layout", tt.numPlayers, l)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package engine
+
+// Move is a complete legal action available to a player.
+// Actions lists, in order, the steps that carry out the move from the current state.
+type Move struct {
+	// Card is the card played by the move, or NoType if the move plays no card.
+	Card CType
+	// As is the card type whose movement rules apply.
+	// It differs from Card only when Jewels are played.
+	As CType
+	// Thief is the position of the thief moved, if any.
+	Thief *Position
+	// To lists the areas in which a thief is placed or to which it moves.
+	To      []Position
+	Actions []Action
+}
+
+// LegalActions returns every legal complete action available to the player identified by pid
+// in the current phase.  It returns nil if pid does not identify the current player.
+func LegalActions(s *State, pid int) []Move {
+	cp := s.CurrentPlayer()
+	if cp == nil || cp.ID != pid {
+		return nil
+	}
+
+	switch s.Phase {
+	case PhasePlaceThieves:
+		if cp.PerformedAction {
+			return []Move{s.finishTurnMove()}
+		}
+		return s.placeThiefMoves()
+	case PhasePlayCard:
+		if cp.PerformedAction {
+			return nil
+		}
+		return append(s.playCardMoves(), s.passMove())
+	case PhaseSelectThief:
+		return append(s.selectThiefMoves(Move{Card: s.playedType(), As: s.PlayedCard.Type}), s.passMove())
+	case PhaseMoveThief:
+		m := Move{Card: s.playedType(), As: s.PlayedCard.Type}
+		return append(s.moveThiefMoves(m), s.passMove())
+	case PhaseDrawCard:
+		return []Move{s.finishTurnMove()}
+	default:
+		return nil
+	}
+}
+
+// IsLegal indicates whether a is the first step of a legal action for the player taking it.
+func IsLegal(s *State, a Action) bool {
+	for _, m := range LegalActions(s, a.PlayerID) {
+		if len(m.Actions) > 0 && m.Actions[0] == a {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *State) playedType() CType {
+	if s.JewelsPlayed {
+		return Jewels
+	}
+	return s.PlayedCard.Type
+}
+
+func (s *State) action(t ActionType) Action {
+	return Action{Type: t, PlayerID: s.CurrentPlayerID}
+}
+
+func (s *State) finishTurnMove() Move {
+	return Move{Actions: []Action{s.action(FinishTurn)}}
+}
+
+func (s *State) passMove() Move {
+	return Move{Actions: []Action{s.action(Pass)}}
+}
+
+func (s *State) placeThiefMoves() (ms []Move) {
+	for _, row := range s.Grid {
+		for _, area := range row {
+			if area.HasCard() && !area.HasThief() {
+				a := s.action(PlaceThief)
+				a.Position = area.Position()
+				ms = append(ms, Move{To: []Position{a.Position}, Actions: []Action{a}})
+			}
+		}
+	}
+	return
+}
+
+func (s *State) playCardMoves() (ms []Move) {
+	cp := s.CurrentPlayer()
+	played := make(map[CType]bool)
+	for _, card := range cp.Hand {
+		if card.Type == Guard || played[card.Type] {
+			continue
+		}
+		played[card.Type] = true
+
+		a := s.action(PlayCard)
+		a.Card = card.Type
+		ns, _, err := Apply(s, a)
+		if err != nil {
+			continue
+		}
+		m := Move{Card: card.Type, As: ns.PlayedCard.Type, Actions: []Action{a}}
+		ms = append(ms, ns.selectThiefMoves(m)...)
+	}
+	return
+}
+
+func (s *State) selectThiefMoves(m Move) (ms []Move) {
+	cp := s.CurrentPlayer()
+	for _, row := range s.Grid {
+		for _, area := range row {
+			if area.Thief != cp.ID {
+				continue
+			}
+
+			a := s.action(SelectThief)
+			a.Position = area.Position()
+			ns, _, err := Apply(s, a)
+			if err != nil {
+				continue
+			}
+			m2 := m.with(a)
+			m2.Thief = &a.Position
+			ms = append(ms, ns.moveThiefMoves(m2)...)
+		}
+	}
+	return
+}
+
+func (s *State) moveThiefMoves(m Move) (ms []Move) {
+	for _, to := range s.Destinations() {
+		a := s.action(MoveThief)
+		a.Position = to.Position()
+		ns, _, err := Apply(s, a)
+		if err != nil {
+			continue
+		}
+		m2 := m.with(a)
+		m2.To = append(append([]Position(nil), m.To...), a.Position)
+		if ns.Phase == PhaseMoveThief {
+			ms = append(ms, ns.moveThiefMoves(m2)...)
+		} else {
+			ms = append(ms, m2)
+		}
+	}
+	return
+}
+
+// with returns a copy of m having a appended to its actions.
+func (m Move) with(a Action) Move {
+	m.Actions = append(append([]Action(nil), m.Actions...), a)
+	return m
+}
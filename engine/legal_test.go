@@ -0,0 +1,163 @@
+package engine
+
+import "testing"
+
+// states returns the states reached while playing a game of the players identified by pids,
+// seeded with seed, beginning with the newly setup game.
+func states(t *testing.T, pids []int, twoThiefVariant bool, seed int64) []*State {
+	t.Helper()
+
+	s := New(pids, twoThiefVariant, seed)
+	ss := []*State{s}
+	_, as := playRandom(t, pids, twoThiefVariant, seed, seed+1)
+	for _, a := range as {
+		var err error
+		if s, _, err = Apply(s, a); err != nil {
+			t.Fatal(err)
+		}
+		ss = append(ss, s)
+	}
+	return ss
+}
+
+func TestLegalActionsApply(t *testing.T) {
+	tests := []struct {
+		name            string
+		pids            []int
+		twoThiefVariant bool
+		seed            int64
+	}{
+		{"two players", []int{1, 2}, false, 11},
+		{"three players", []int{1, 2, 3}, false, 12},
+		{"four players", []int{1, 2, 3, 4}, false, 13},
+		{"two thief variant", []int{1, 2, 3}, true, 14},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, s := range states(t, tt.pids, tt.twoThiefVariant, tt.seed) {
+				ms := LegalActions(s, s.CurrentPlayerID)
+				if s.Phase != PhaseGameOver && len(ms) == 0 {
+					t.Fatalf("no legal moves in the %q phase", s.Phase)
+				}
+
+				for _, m := range ms {
+					if len(m.Actions) == 0 {
+						t.Fatalf("move %+v has no actions", m)
+					}
+					if !IsLegal(s, m.Actions[0]) {
+						t.Errorf("IsLegal rejects the first action of legal move %+v", m.Actions)
+					}
+					if _, err := Play(s, m); err != nil {
+						t.Errorf("Apply rejects legal move %+v: %v", m.Actions, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLegalActionsOtherPlayers(t *testing.T) {
+	for _, s := range states(t, []int{1, 2, 3}, false, 15) {
+		for _, p := range s.Players {
+			if p.ID == s.CurrentPlayerID {
+				continue
+			}
+			if ms := LegalActions(s, p.ID); ms != nil {
+				t.Fatalf("player %d, who is not the current player, has %d legal moves in the %q phase", p.ID, len(ms), s.Phase)
+			}
+		}
+	}
+}
+
+func TestLegalPlaceThief(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(*State)
+	}{
+		{"new game", func(*State) {}},
+		{"occupied areas", func(s *State) {
+			s.Grid[0][0].Thief = s.Players[0].ID
+			s.Grid[1][2].Thief = s.Players[1].ID
+		}},
+		{"claimed areas", func(s *State) {
+			s.Grid[0][1].Card = nil
+			s.Grid[2][2].Card = nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New([]int{1, 2}, false, 16)
+			tt.setup(s)
+
+			for _, row := range s.Grid {
+				for _, area := range row {
+					a := Action{Type: PlaceThief, PlayerID: s.CurrentPlayerID, Position: area.Position()}
+					_, _, err := Apply(s, a)
+					if legal := IsLegal(s, a); legal != (err == nil) {
+						t.Errorf("placing a thief at %s: IsLegal returned %t, but Apply returned error %v",
+							area.Position().Label(), legal, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLegalActionsPassLast(t *testing.T) {
+	for _, s := range states(t, []int{1, 2}, false, 17) {
+		ms := LegalActions(s, s.CurrentPlayerID)
+		for i, m := range ms {
+			isPass := len(m.Actions) == 1 && m.Actions[0].Type == Pass
+			if isPass && i != len(ms)-1 {
+				t.Fatalf("pass is move %d of %d in the %q phase", i+1, len(ms), s.Phase)
+			}
+		}
+		switch s.Phase {
+		case PhasePlayCard, PhaseSelectThief, PhaseMoveThief:
+			if cp := s.CurrentPlayer(); cp.PerformedAction {
+				continue
+			}
+			if last := ms[len(ms)-1]; last.Actions[0].Type != Pass {
+				t.Fatalf("the last move of the %q phase is %v, not a pass", s.Phase, last.Actions[0].Type)
+			}
+		}
+	}
+}
+
+func TestPlayTurn(t *testing.T) {
+	tests := []struct {
+		name string
+		pids []int
+		seed int64
+	}{
+		{"two players", []int{1, 2}, 18},
+		{"three players", []int{3, 1, 2}, 19},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(tt.pids, false, tt.seed)
+			r := NewRand(tt.seed)
+			for turns := 0; s.Phase != PhaseGameOver; turns++ {
+				if turns > 1000 {
+					t.Fatal("the game did not end")
+				}
+
+				pid := s.CurrentPlayerID
+				m := RandomStrategy{}.Choose(s, pid, LegalActions(s, pid), &r)
+				ns, err := PlayTurn(s, m)
+				if err != nil {
+					t.Fatalf("playing %+v: %v", m.Actions, err)
+				}
+				if ns.CurrentPlayerID == pid && ns.Phase != PhaseGameOver {
+					if ms := LegalActions(ns, pid); len(ms) == 1 && isFinishTurn(ms[0]) {
+						t.Fatalf("PlayTurn left the turn of player %d unfinished after %+v", pid, m.Actions)
+					}
+				}
+				s = ns
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package engine
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of an MCTSStrategy not specifying its budget or playouts.
+const (
+	defaultIterations = 2000
+	defaultPlayout    = 2
+)
+
+// exploration weights the exploration term of the UCB1 formula used to select moves.
+const exploration = 0.7
+
+// rewardScale is the difference in value at which a position is worth about three quarters of a win.
+const rewardScale = 4
+
+// MCTSStrategy chooses moves by information set Monte Carlo tree search.
+// Each iteration determinizes the information hidden from the player, as Determinize does, descends
+// a single tree of turns shared by every determinization, and completes the determinization by a playout.
+// Search stops once Iterations iterations have been run or Budget has elapsed, whichever comes first.
+// If neither is set, defaultIterations iterations are run.
+type MCTSStrategy struct {
+	Iterations int
+	Budget     time.Duration
+	// Playout is the number of rounds played after leaving the tree before the position is valued.
+	Playout int
+}
+
+// mctsNode records the statistics of a turn in the search tree.
+type mctsNode struct {
+	move     Move
+	pid      int
+	children map[string]*mctsNode
+	// visits counts the iterations passing through the node.
+	// avail counts the iterations in which its move was available to be chosen.
+	visits, avail int
+	// reward totals the rewards to the player taking the turn over the iterations passing through the node.
+	reward float64
+}
+
+func newMCTSNode(m Move, pid int) *mctsNode {
+	return &mctsNode{move: m, pid: pid, children: make(map[string]*mctsNode)}
+}
+
+// Choose implements Strategy.
+func (ms MCTSStrategy) Choose(s *State, pid int, moves []Move, r *Rand) Move {
+	iterations, playout := ms.Iterations, ms.Playout
+	if iterations <= 0 && ms.Budget <= 0 {
+		iterations = defaultIterations
+	}
+	if playout <= 0 {
+		playout = defaultPlayout
+	}
+
+	var deadline time.Time
+	if ms.Budget > 0 {
+		deadline = time.Now().Add(ms.Budget)
+	}
+
+	root := newMCTSNode(Move{}, NoPID)
+	for i := 0; iterations <= 0 || i < iterations; i++ {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			break
+		}
+		iterate(root, Determinize(s, pid, r), moves, playout, r)
+	}
+
+	var best *mctsNode
+	for _, m := range moves {
+		if n := root.children[moveKey(m)]; n != nil && (best == nil || n.visits > best.visits) {
+			best = n
+		}
+	}
+	if best == nil {
+		return moves[r.Intn(len(moves))]
+	}
+	return best.move
+}
+
+// iterate runs one iteration of the search from root over the determinization d.
+// The moves available at the root are restricted to moves.
+func iterate(root *mctsNode, d *State, moves []Move, playout int, r *Rand) {
+	path := []*mctsNode{root}
+	n := root
+	for d.Phase != PhaseGameOver {
+		cp := d.CurrentPlayer()
+		if cp == nil {
+			break
+		}
+
+		ms := moves
+		if n != root {
+			ms = LegalActions(d, cp.ID)
+		}
+		if len(ms) == 0 {
+			break
+		}
+
+		child, expanded := n.selectChild(cp.ID, ms, r)
+		ns, err := PlayTurn(d, child.move)
+		if err != nil {
+			// The move is left untried, rather than kept in the tree unvisited.
+			if expanded {
+				delete(n.children, moveKey(child.move))
+			}
+			break
+		}
+		d, n = ns, child
+		path = append(path, n)
+		if expanded {
+			break
+		}
+	}
+
+	d = playOut(d, playout*len(d.Players), r)
+	for _, n := range path {
+		n.visits++
+		if n.pid != NoPID {
+			n.reward += reward(d, n.pid)
+		}
+	}
+}
+
+// selectChild returns the child of n to descend to among the moves ms available to the player identified by pid.
+// If some of the moves have yet to be tried, one of them is added to the tree and expanded is true.
+// A child not yet visited is selected before any other.
+func (n *mctsNode) selectChild(pid int, ms []Move, r *Rand) (child *mctsNode, expanded bool) {
+	var untried []Move
+	for _, m := range ms {
+		if c := n.children[moveKey(m)]; c != nil {
+			c.avail++
+		} else {
+			untried = append(untried, m)
+		}
+	}
+
+	if len(untried) > 0 {
+		m := untried[r.Intn(len(untried))]
+		child = newMCTSNode(m, pid)
+		child.avail = 1
+		n.children[moveKey(m)] = child
+		return child, true
+	}
+
+	bestV := math.Inf(-1)
+	for _, m := range ms {
+		c := n.children[moveKey(m)]
+		if c.visits == 0 {
+			return c, false
+		}
+
+		v := c.reward/float64(c.visits) + exploration*math.Sqrt(math.Log(float64(c.avail))/float64(c.visits))
+		if v > bestV {
+			child, bestV = c, v
+		}
+	}
+	return child, false
+}
+
+// playOut plays up to turns turns of s, choosing randomly among the moves other than passing while any remain.
+func playOut(s *State, turns int, r *Rand) *State {
+	for i := 0; i < turns && s.Phase != PhaseGameOver; i++ {
+		cp := s.CurrentPlayer()
+		if cp == nil {
+			break
+		}
+
+		ms := LegalActions(s, cp.ID)
+		if len(ms) == 0 {
+			break
+		}
+
+		// Passing, when available, is the last of the legal moves.
+		m := ms[r.Intn(len(ms))]
+		if len(ms) > 1 && isPass(m) {
+			m = ms[r.Intn(len(ms)-1)]
+		}
+
+		ns, err := PlayTurn(s, m)
+		if err != nil {
+			break
+		}
+		s = ns
+	}
+	return s
+}
+
+// reward returns the reward, between 0 and 1, of state s to the player identified by pid.
+func reward(s *State, pid int) float64 {
+	return 1 / (1 + math.Exp(-Evaluate(s, pid)*math.Log(3)/rewardScale))
+}
+
+func isPass(m Move) bool {
+	return len(m.Actions) == 1 && m.Actions[0].Type == Pass
+}
+
+// moveKey identifies a move independently of the determinization in which it is available.
+func moveKey(m Move) string {
+	var b strings.Builder
+	for _, a := range m.Actions {
+		b.WriteString(strconv.Itoa(int(a.Type)))
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(a.Row))
+		b.WriteByte(',')
+		b.WriteString(strconv.Itoa(a.Column))
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(int(a.Card)))
+		b.WriteByte(';')
+	}
+	return b.String()
+}
@@ -0,0 +1,41 @@
+package engine
+
+import "testing"
+
+func TestSelectChildUnvisited(t *testing.T) {
+	m1 := Move{Actions: []Action{{Type: PlayCard, Card: Lamp}}}
+	m2 := Move{Actions: []Action{{Type: PlayCard, Card: Camel}}}
+
+	n := newMCTSNode(Move{}, NoPID)
+	visited := newMCTSNode(m1, 0)
+	visited.visits, visited.avail, visited.reward = 3, 3, 2
+	unvisited := newMCTSNode(m2, 0)
+	n.children[moveKey(m1)], n.children[moveKey(m2)] = visited, unvisited
+
+	r := NewRand(1)
+	child, expanded := n.selectChild(0, []Move{m1, m2}, &r)
+	if child != unvisited || expanded {
+		t.Errorf("got child %+v, expanded %t, want the unvisited child", child, expanded)
+	}
+}
+
+func TestIterateDropsFailedMove(t *testing.T) {
+	s := New([]int{0, 1}, false, 1)
+	bad := Move{Actions: []Action{{Type: MoveThief, PlayerID: s.CurrentPlayerID}}}
+	if _, err := PlayTurn(s, bad); err == nil {
+		t.Fatal("moving a thief while placing thieves succeeded")
+	}
+
+	r := NewRand(2)
+	root := newMCTSNode(Move{}, NoPID)
+	iterate(root, s, []Move{bad}, 1, &r)
+	if len(root.children) != 0 {
+		t.Errorf("the failed move remains in the tree as %+v", root.children)
+	}
+
+	moves := append([]Move{bad}, LegalActions(s, s.CurrentPlayerID)...)
+	m := MCTSStrategy{Iterations: 200}.Choose(s, s.CurrentPlayerID, moves, &r)
+	if _, err := PlayTurn(s, m); err != nil {
+		t.Errorf("chose move %+v failing with %v", m.Actions, err)
+	}
+}
@@ -0,0 +1,564 @@
+package engine
+
+// Destinations returns the areas to which the selected thief may move given the played card.
+func (s *State) Destinations() Areas {
+	a, cp := s.SelectedThiefArea(), s.CurrentPlayer()
+	if a == nil || cp == nil || s.PlayedCard == nil {
+		return nil
+	}
+	return s.destinationsFor(cp, a, s.PlayedCard.Type, s.Stepped).uniq()
+}
+
+func (s *State) destinationsFor(cp *Player, a *Area, t CType, stepped int) Areas {
+	switch {
+	case t == Lamp || t == StartLamp:
+		return s.lampAreas(a)
+	case t == Camel || t == StartCamel:
+		return s.camelAreas(a)
+	case t == Sword:
+		return s.swordAreas(cp, a)
+	case t == Carpet:
+		return s.carpetAreas(a)
+	case t == Turban && stepped == 0:
+		return s.turban0Areas(a)
+	case t == Turban && stepped == 1:
+		return s.turban1Areas(a)
+	case t == Coins:
+		return s.coinsAreas(a)
+	default:
+		return nil
+	}
+}
+
+func (s *State) lampAreas(a1 *Area) Areas {
+	as := make(Areas, 0)
+	// Move Left
+	var a2 *Area
+	for col := a1.Column - 1; col >= col1; col-- {
+		if temp := s.Grid[a1.Row][col]; !canMoveTo(temp) {
+			break
+		} else {
+			a2 = temp
+		}
+	}
+	if a2 != nil {
+		as = append(as, a2)
+	}
+
+	// Move right
+	a2 = nil
+	for col := a1.Column + 1; col <= s.lastCol(); col++ {
+		if temp := s.Grid[a1.Row][col]; !canMoveTo(temp) {
+			break
+		} else {
+			a2 = temp
+		}
+	}
+	if a2 != nil {
+		as = append(as, a2)
+	}
+
+	// Move Up
+	a2 = nil
+	for row := a1.Row - 1; row >= rowA; row-- {
+		if temp := s.Grid[row][a1.Column]; !canMoveTo(temp) {
+			break
+		} else {
+			a2 = temp
+		}
+	}
+	if a2 != nil {
+		as = append(as, a2)
+	}
+
+	// Move Down
+	a2 = nil
+	for row := a1.Row + 1; row <= s.lastRow(); row++ {
+		if temp := s.Grid[row][a1.Column]; !canMoveTo(temp) {
+			break
+		} else {
+			a2 = temp
+		}
+	}
+	if a2 != nil {
+		as = append(as, a2)
+	}
+	return as
+}
+
+func (s *State) camelAreas(a *Area) Areas {
+	as := make(Areas, 0)
+
+	// Move Three Left?
+	if a.Column-3 >= col1 {
+		area1 := s.Grid[a.Row][a.Column-1]
+		area2 := s.Grid[a.Row][a.Column-2]
+		area3 := s.Grid[a.Row][a.Column-3]
+		if canMoveTo(area1, area2, area3) {
+			as = append(as, area3)
+		}
+	}
+
+	// Move Three Right?
+	if a.Column+3 <= s.lastCol() {
+		area1 := s.Grid[a.Row][a.Column+1]
+		area2 := s.Grid[a.Row][a.Column+2]
+		area3 := s.Grid[a.Row][a.Column+3]
+		if canMoveTo(area1, area2, area3) {
+			as = append(as, area3)
+		}
+	}
+
+	// Move Three Up?
+	if a.Row-3 >= rowA {
+		area1 := s.Grid[a.Row-1][a.Column]
+		area2 := s.Grid[a.Row-2][a.Column]
+		area3 := s.Grid[a.Row-3][a.Column]
+		if canMoveTo(area1, area2, area3) {
+			as = append(as, area3)
+		}
+	}
+
+	// Move Three Down?
+	if a.Row+3 <= s.lastRow() {
+		area1 := s.Grid[a.Row+1][a.Column]
+		area2 := s.Grid[a.Row+2][a.Column]
+		area3 := s.Grid[a.Row+3][a.Column]
+		if canMoveTo(area1, area2, area3) {
+			as = append(as, area3)
+		}
+	}
+
+	// Move Two Left One Up or One Up Two Left or One Left One Up One Left?
+	if a.Column-2 >= col1 && a.Row-1 >= rowA {
+		area1 := s.Grid[a.Row][a.Column-1]
+		area2 := s.Grid[a.Row][a.Column-2]
+		area3 := s.Grid[a.Row-1][a.Column-2]
+		area4 := s.Grid[a.Row-1][a.Column]
+		area5 := s.Grid[a.Row-1][a.Column-1]
+		if canMoveTo(area1, area2, area3) || canMoveTo(area3, area4, area5) || canMoveTo(area1, area5, area3) {
+			as = append(as, area3)
+		}
+	}
+
+	// Move Two Left One Down or One Down Two Left or One Left One Down One Left?
+	if a.Column-2 >= col1 && a.Row+1 <= s.lastRow() {
+		area1 := s.Grid[a.Row][a.Column-1]
+		area2 := s.Grid[a.Row][a.Column-2]
+		area3 := s.Grid[a.Row+1][a.Column-2]
+		area4 := s.Grid[a.Row+1][a.Column]
+		area5 := s.Grid[a.Row+1][a.Column-1]
+		if canMoveTo(area1, area2, area3) || canMoveTo(area3, area4, area5) || canMoveTo(area1, area5, area3) {
+			as = append(as, area3)
+		}
+	}
+
+	// Move Two Right One Up or One Up Two Right or One Right One Up One Right?
+	if a.Column+2 <= s.lastCol() && a.Row-1 >= rowA {
+		area1 := s.Grid[a.Row][a.Column+1]
+		area2 := s.Grid[a.Row][a.Column+2]
+		area3 := s.Grid[a.Row-1][a.Column+2]
+		area4 := s.Grid[a.Row-1][a.Column]
+		area5 := s.Grid[a.Row-1][a.Column+1]
+		if canMoveTo(area1, area2, area3) || canMoveTo(area3, area4, area5) || canMoveTo(area1, area5, area3) {
+			as = append(as, area3)
+		}
+	}
+
+	// Move Two Right One Down or One Down Two Right or One Right One Down One Right?
+	if a.Column+2 <= s.lastCol() && a.Row+1 <= s.lastRow() {
+		area1 := s.Grid[a.Row][a.Column+1]
+		area2 := s.Grid[a.Row][a.Column+2]
+		area3 := s.Grid[a.Row+1][a.Column+2]
+		area4 := s.Grid[a.Row+1][a.Column]
+		area5 := s.Grid[a.Row+1][a.Column+1]
+		if canMoveTo(area1, area2, area3) || canMoveTo(area3, area4, area5) || canMoveTo(area1, area5, area3) {
+			as = append(as, area3)
+		}
+	}
+
+	// Move One Right Two Down or Two Down One Right or One Down One Right One Down?
+	if a.Column+1 <= s.lastCol() && a.Row+2 <= s.lastRow() {
+		area1 := s.Grid[a.Row+1][a.Column]
+		area2 := s.Grid[a.Row+2][a.Column]
+		area3 := s.Grid[a.Row+2][a.Column+1]
+		area4 := s.Grid[a.Row][a.Column+1]
+		area5 := s.Grid[a.Row+1][a.Column+1]
+		if canMoveTo(area1, area2, area3) || canMoveTo(area3, area4, area5) || canMoveTo(area1, area5, area3) {
+			as = append(as, area3)
+		}
+	}
+
+	// Move One Right Two Up or Two Up One Right or One Up One Right One Up?
+	if a.Column+1 <= s.lastCol() && a.Row-2 >= rowA {
+		area1 := s.Grid[a.Row-1][a.Column]
+		area2 := s.Grid[a.Row-2][a.Column]
+		area3 := s.Grid[a.Row-2][a.Column+1]
+		area4 := s.Grid[a.Row][a.Column+1]
+		area5 := s.Grid[a.Row-1][a.Column+1]
+		if canMoveTo(area1, area2, area3) || canMoveTo(area3, area4, area5) || canMoveTo(area1, area5, area3) {
+			as = append(as, area3)
+		}
+	}
+
+	// Move One Left Two Down or Two Down One Left or One Down One Left One Down?
+	if a.Column-1 >= col1 && a.Row+2 <= s.lastRow() {
+		area1 := s.Grid[a.Row+1][a.Column]
+		area2 := s.Grid[a.Row+2][a.Column]
+		area3 := s.Grid[a.Row+2][a.Column-1]
+		area4 := s.Grid[a.Row][a.Column-1]
+		area5 := s.Grid[a.Row+1][a.Column-1]
+		if canMoveTo(area1, area2, area3) || canMoveTo(area3, area4, area5) || canMoveTo(area1, area5, area3) {
+			as = append(as, area3)
+		}
+	}
+
+	// Move One Left Two Up or Two Up One Left or One Up One Left One Up?
+	if a.Column-1 >= col1 && a.Row-2 >= rowA {
+		area1 := s.Grid[a.Row-1][a.Column]
+		area2 := s.Grid[a.Row-2][a.Column]
+		area3 := s.Grid[a.Row-2][a.Column-1]
+		area4 := s.Grid[a.Row][a.Column-1]
+		area5 := s.Grid[a.Row-1][a.Column-1]
+		if canMoveTo(area1, area2, area3) || canMoveTo(area3, area4, area5) || canMoveTo(area1, area5, area3) {
+			as = append(as, area3)
+		}
+	}
+
+	// Move One Left One Up One Right or One Up One Left One Down?
+	if a.Column-1 >= col1 && a.Row-1 >= rowA {
+		area1 := s.Grid[a.Row][a.Column-1]
+		area2 := s.Grid[a.Row-1][a.Column-1]
+		area3 := s.Grid[a.Row-1][a.Column]
+		if canMoveTo(area1, area2, area3) {
+			as = append(as, area1)
+			as = append(as, area3)
+		}
+	}
+
+	// Move One Up One Right One Down or One Right One Up One Left?
+	if a.Column+1 <= s.lastCol() && a.Row-1 >= rowA {
+		area1 := s.Grid[a.Row][a.Column+1]
+		area2 := s.Grid[a.Row-1][a.Column+1]
+		area3 := s.Grid[a.Row-1][a.Column]
+		if canMoveTo(area1, area2, area3) {
+			as = append(as, area1)
+			as = append(as, area3)
+		}
+	}
+
+	// Move One Left One Down One Right or One Down One Left One Up?
+	if a.Column-1 >= col1 && a.Row+1 <= s.lastRow() {
+		area1 := s.Grid[a.Row][a.Column-1]
+		area2 := s.Grid[a.Row+1][a.Column-1]
+		area3 := s.Grid[a.Row+1][a.Column]
+		if canMoveTo(area1, area2, area3) {
+			as = append(as, area1)
+			as = append(as, area3)
+		}
+	}
+
+	// Move One Down One Right One Up or One Right One Down One Left?
+	if a.Column+1 <= s.lastCol() && a.Row+1 <= s.lastRow() {
+		area1 := s.Grid[a.Row][a.Column+1]
+		area2 := s.Grid[a.Row+1][a.Column+1]
+		area3 := s.Grid[a.Row+1][a.Column]
+		if canMoveTo(area1, area2, area3) {
+			as = append(as, area1)
+			as = append(as, area3)
+		}
+	}
+
+	return as
+}
+
+func (as Areas) uniq() Areas {
+	u := make(Areas, 0, len(as))
+	for _, a := range as {
+		if !u.Include(a) {
+			u = append(u, a)
+		}
+	}
+	return u
+}
+
+func canMoveTo(as ...*Area) bool {
+	for _, a := range as {
+		if a.HasThief() || !a.HasCard() {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *State) swordAreas(cp *Player, a *Area) Areas {
+	as := make(Areas, 0)
+
+	// Move Left
+	if area, row := a, a.Row; a.Column >= col3 {
+		// Left as far as permitted
+		for col := a.Column - 1; col >= col3; col-- {
+			if temp := s.Grid[row][col]; !canMoveTo(temp) {
+				break
+			} else {
+				area = temp
+			}
+		}
+
+		// Check for Thief and Place to Bump
+		moveTo, bumpTo := s.Grid[row][area.Column-1], s.Grid[row][area.Column-2]
+		if s.opposingThiefIn(cp, moveTo) && canMoveTo(bumpTo) {
+			as = append(as, moveTo)
+		}
+	}
+
+	// Move Right
+	if area, row := a, a.Row; a.Column <= s.lastCol()-2 {
+		// Right as far as permitted
+		for col := a.Column + 1; col <= s.lastCol()-2; col++ {
+			if temp := s.Grid[row][col]; !canMoveTo(temp) {
+				break
+			} else {
+				area = temp
+			}
+		}
+
+		// Check for Thief and Place to Bump
+		moveTo, bumpTo := s.Grid[row][area.Column+1], s.Grid[row][area.Column+2]
+		if s.opposingThiefIn(cp, moveTo) && canMoveTo(bumpTo) {
+			as = append(as, moveTo)
+		}
+	}
+
+	// Move Up
+	if area, col := a, a.Column; a.Row >= rowC {
+		// Up as far as permitted
+		for row := a.Row - 1; row >= rowC; row-- {
+			if temp := s.Grid[row][col]; !canMoveTo(temp) {
+				break
+			} else {
+				area = temp
+			}
+		}
+
+		// Check for Thief and Place to Bump
+		moveTo, bumpTo := s.Grid[area.Row-1][col], s.Grid[area.Row-2][col]
+		if s.opposingThiefIn(cp, moveTo) && canMoveTo(bumpTo) {
+			as = append(as, moveTo)
+		}
+	}
+
+	// Move Down
+	if area, col := a, a.Column; a.Row <= s.lastRow()-2 {
+		// Down as far as permitted
+		for row := a.Row + 1; row <= s.lastRow()-2; row++ {
+			if temp := s.Grid[row][col]; !canMoveTo(temp) {
+				break
+			} else {
+				area = temp
+			}
+		}
+
+		// Check for Thief and Place to Bump
+		moveTo, bumpTo := s.Grid[area.Row+1][col], s.Grid[area.Row+2][col]
+		if s.opposingThiefIn(cp, moveTo) && canMoveTo(bumpTo) {
+			as = append(as, moveTo)
+		}
+	}
+
+	return as
+}
+
+func (s *State) carpetAreas(a1 *Area) Areas {
+	as := make(Areas, 0)
+
+	// Move Left
+	var a2, empty *Area
+MoveLeft:
+	for col := a1.Column - 1; col >= col1; col-- {
+		switch temp := s.Grid[a1.Row][col]; {
+		case temp.Blocked:
+			break MoveLeft
+		case temp.Card == nil:
+			empty = temp
+		case empty != nil && canMoveTo(temp):
+			a2 = temp
+			break MoveLeft
+		default:
+			break MoveLeft
+		}
+	}
+	if a2 != nil {
+		as = append(as, a2)
+	}
+
+	// Move Right
+	a2, empty = nil, nil
+MoveRight:
+	for col := a1.Column + 1; col <= s.lastCol(); col++ {
+		switch temp := s.Grid[a1.Row][col]; {
+		case temp.Blocked:
+			break MoveRight
+		case temp.Card == nil:
+			empty = temp
+		case empty != nil && canMoveTo(temp):
+			a2 = temp
+			break MoveRight
+		default:
+			break MoveRight
+		}
+	}
+	if a2 != nil {
+		as = append(as, a2)
+	}
+
+	// Move Up
+	a2, empty = nil, nil
+MoveUp:
+	for row := a1.Row - 1; row >= rowA; row-- {
+		switch temp := s.Grid[row][a1.Column]; {
+		case temp.Blocked:
+			break MoveUp
+		case temp.Card == nil:
+			empty = temp
+		case empty != nil && canMoveTo(temp):
+			a2 = temp
+			break MoveUp
+		default:
+			break MoveUp
+		}
+	}
+	if a2 != nil {
+		as = append(as, a2)
+	}
+
+	// Move Down
+	a2, empty = nil, nil
+MoveDown:
+	for row := a1.Row + 1; row <= s.lastRow(); row++ {
+		switch temp := s.Grid[row][a1.Column]; {
+		case temp.Blocked:
+			break MoveDown
+		case temp.Card == nil:
+			empty = temp
+		case empty != nil && canMoveTo(temp):
+			a2 = temp
+			break MoveDown
+		default:
+			break MoveDown
+		}
+	}
+	if a2 != nil {
+		as = append(as, a2)
+	}
+
+	return as
+}
+
+func (s *State) turban0Areas(a *Area) Areas {
+	as := make(Areas, 0)
+
+	// Move Left
+	if col := a.Column - 1; col >= col1 {
+		if area := s.Grid[a.Row][col]; canMoveTo(area) {
+			// Left
+			if col := col - 1; col >= col1 && canMoveTo(s.Grid[area.Row][col]) {
+				as = append(as, area)
+			}
+			// Up
+			if row := area.Row - 1; row >= rowA && canMoveTo(s.Grid[row][col]) {
+				as = append(as, area)
+			}
+			// Down
+			if row := area.Row + 1; row <= s.lastRow() && canMoveTo(s.Grid[row][col]) {
+				as = append(as, area)
+			}
+		}
+	}
+
+	// Move Right
+	if col := a.Column + 1; col <= s.lastCol() {
+		if area := s.Grid[a.Row][col]; canMoveTo(area) {
+			// Right
+			if col := col + 1; col <= s.lastCol() && canMoveTo(s.Grid[area.Row][col]) {
+				as = append(as, area)
+			}
+			// Up
+			if row := area.Row - 1; row >= rowA && canMoveTo(s.Grid[row][col]) {
+				as = append(as, area)
+			}
+			// Down
+			if row := area.Row + 1; row <= s.lastRow() && canMoveTo(s.Grid[row][col]) {
+				as = append(as, area)
+			}
+		}
+	}
+
+	// Move Up
+	if row := a.Row - 1; row >= rowA {
+		if area := s.Grid[row][a.Column]; canMoveTo(area) {
+			// Left
+			if col := area.Column - 1; col >= col1 && canMoveTo(s.Grid[row][col]) {
+				as = append(as, area)
+			}
+			// Right
+			if col := area.Column + 1; col <= s.lastCol() && canMoveTo(s.Grid[area.Row][col]) {
+				as = append(as, area)
+			}
+			// Up
+			if row := row - 1; row >= rowA && canMoveTo(s.Grid[row][a.Column]) {
+				as = append(as, area)
+			}
+		}
+	}
+
+	// Move Down
+	if row := a.Row + 1; row <= s.lastRow() {
+		if area := s.Grid[row][a.Column]; canMoveTo(area) {
+			// Left
+			if col := area.Column - 1; col >= col1 && canMoveTo(s.Grid[row][col]) {
+				as = append(as, area)
+			}
+			// Right
+			if col := area.Column + 1; col <= s.lastCol() && canMoveTo(s.Grid[area.Row][col]) {
+				as = append(as, area)
+			}
+			// Down
+			if row := row + 1; row <= s.lastRow() && canMoveTo(s.Grid[row][a.Column]) {
+				as = append(as, area)
+			}
+		}
+	}
+
+	return as
+}
+
+func (s *State) turban1Areas(a *Area) Areas {
+	as := make(Areas, 0)
+
+	// Move Left
+	if a.Column-1 >= col1 && canMoveTo(s.Grid[a.Row][a.Column-1]) {
+		as = append(as, s.Grid[a.Row][a.Column-1])
+	}
+
+	// Move Right
+	if a.Column+1 <= s.lastCol() && canMoveTo(s.Grid[a.Row][a.Column+1]) {
+		as = append(as, s.Grid[a.Row][a.Column+1])
+	}
+
+	// Move Up
+	if a.Row-1 >= rowA && canMoveTo(s.Grid[a.Row-1][a.Column]) {
+		as = append(as, s.Grid[a.Row-1][a.Column])
+	}
+
+	// Move Down
+	if a.Row+1 <= s.lastRow() && canMoveTo(s.Grid[a.Row+1][a.Column]) {
+		as = append(as, s.Grid[a.Row+1][a.Column])
+	}
+
+	return as
+}
+
+func (s *State) coinsAreas(a *Area) Areas {
+	return s.turban1Areas(a)
+}
@@ -0,0 +1,43 @@
+package engine
+
+// Phase identifies a phase of the game.
+type Phase int
+
+// Phases of the game.
+const (
+	NoPhase Phase = iota
+	PhaseSetup
+	PhaseStartGame
+	PhasePlaceThieves
+	PhasePlayCard
+	PhaseSelectThief
+	PhaseMoveThief
+	PhaseClaimItem
+	PhaseDrawCard
+	PhaseFinalClaim
+	PhaseAnnounceWinners
+	PhaseGameOver
+	PhaseEndGame
+	PhaseAwaitPlayerInput
+)
+
+var phaseNames = map[Phase]string{
+	NoPhase:               "None",
+	PhaseSetup:            "Setup",
+	PhaseStartGame:        "Start Game",
+	PhasePlaceThieves:     "Place Thieves",
+	PhasePlayCard:         "Play Card",
+	PhaseSelectThief:      "Select Thief",
+	PhaseMoveThief:        "Move Thief",
+	PhaseClaimItem:        "Claim Magical Item",
+	PhaseDrawCard:         "Draw Card",
+	PhaseFinalClaim:       "Final Claim",
+	PhaseAnnounceWinners:  "Announce Winners",
+	PhaseGameOver:         "Game Over",
+	PhaseEndGame:          "End Of Game",
+	PhaseAwaitPlayerInput: "Await Player Input",
+}
+
+func (p Phase) String() string {
+	return phaseNames[p]
+}
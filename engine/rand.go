@@ -0,0 +1,41 @@
+package engine
+
+import "math"
+
+// Rand is a deterministic source of pseudo-random numbers.
+// Its entire state is its seed and the number of values drawn so far,
+// so it may be persisted with the game and resumed exactly.
+type Rand struct {
+	Seed int64
+	Pos  uint64
+}
+
+// NewRand returns a source of pseudo-random numbers generated from seed.
+func NewRand(seed int64) Rand {
+	return Rand{Seed: seed}
+}
+
+// Uint64 returns the next pseudo-random value of the stream using the splitmix64 generator.
+func (r *Rand) Uint64() uint64 {
+	r.Pos++
+	z := uint64(r.Seed) + r.Pos*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Intn returns a pseudo-random number in [0,n).  It panics if n <= 0.
+func (r *Rand) Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn")
+	}
+
+	// Reject the values at the top of the range that would bias the result.
+	bound := uint64(n)
+	limit := math.MaxUint64 - (math.MaxUint64%bound+1)%bound
+	for {
+		if v := r.Uint64(); v <= limit {
+			return int(v % bound)
+		}
+	}
+}
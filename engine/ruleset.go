@@ -0,0 +1,197 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Ruleset defines the cards of a game: the composition of the deck from which the grid is dealt,
+// the point value of each card type, and the hand each player begins with.
+// A ruleset must not be modified once a game uses it.
+type Ruleset struct {
+	Name string
+	// Deck gives the number of copies of each card type in the deck.
+	Deck map[CType]int
+	// Values gives the point value of each card type.  Types not listed are worth nothing.
+	Values map[CType]int
+	// StartHand lists the cards each player begins the game with.
+	StartHand []CType
+	// Layout defines the board.  Nil indicates the default layout for the number of players.
+	Layout *Layout
+}
+
+// StandardRuleset is the name of the ruleset of the published game.
+const StandardRuleset = "standard"
+
+// deckTypes lists, in the order in which they are dealt into the deck, the card types a deck may hold.
+var deckTypes = []CType{Lamp, Camel, Sword, Carpet, Coins, Turban, Jewels, Guard}
+
+var standardRuleset = &Ruleset{
+	Name: StandardRuleset,
+	Deck: map[CType]int{
+		Lamp:   8,
+		Camel:  8,
+		Sword:  8,
+		Carpet: 8,
+		Coins:  8,
+		Turban: 8,
+		Jewels: 8,
+		Guard:  8,
+	},
+	Values:    ctypeValues,
+	StartHand: []CType{StartLamp, StartLamp, StartCamel},
+}
+
+// Standard returns the ruleset of the published game.
+func Standard() *Ruleset {
+	return standardRuleset
+}
+
+// Validate returns an error if the ruleset cannot be used for a game of numPlayers players,
+// each having two thieves if twoThiefVariant is set and three otherwise.
+func (rs *Ruleset) Validate(numPlayers int, twoThiefVariant bool) error {
+	l := rs.layoutFor(numPlayers)
+	if err := l.Validate(numPlayers, thievesPerPlayer(twoThiefVariant)); err != nil {
+		return err
+	}
+
+	if numPlayers < MinPlayers || numPlayers > MaxPlayers {
+		return fmt.Errorf("a game must have from %d to %d players, but has %d", MinPlayers, MaxPlayers, numPlayers)
+	}
+
+	for t, n := range rs.Deck {
+		if !isDeckType(t) {
+			return fmt.Errorf("the deck may not hold %s cards", t.IDString())
+		}
+		if n < 0 {
+			return fmt.Errorf("the deck may not hold %d %s cards", n, t.IDString())
+		}
+	}
+
+	if total, size := len(rs.deckFor(numPlayers)), l.Squares(); total < size {
+		return fmt.Errorf("the deck holds %d cards, but the board for %d players requires %d", total, numPlayers, size)
+	}
+
+	if len(rs.StartHand) == 0 {
+		return errors.New("the starting hand must hold at least one card")
+	}
+	for _, t := range rs.StartHand {
+		if t == NoType || t == Guard || ctypeStrings[t] == "" {
+			return fmt.Errorf("the starting hand may not hold %s cards", t.IDString())
+		}
+	}
+	return nil
+}
+
+func isDeckType(t CType) bool {
+	for _, dt := range deckTypes {
+		if t == dt {
+			return true
+		}
+	}
+	return false
+}
+
+// Value returns the point value of cards of type t.
+func (rs *Ruleset) Value(t CType) int {
+	return rs.Values[t]
+}
+
+// layoutFor returns the layout of the board for a game of numPlayers players.
+func (rs *Ruleset) layoutFor(numPlayers int) *Layout {
+	if rs.Layout == nil {
+		return DefaultLayout(numPlayers)
+	}
+	return rs.Layout
+}
+
+// largeGame is the least number of players for which the deck is enlarged to cover the board.
+const largeGame = 5
+
+// deckFor returns the cards of the deck for a game of numPlayers players, dealing one of each remaining type in turn.
+// For games of five or more players, the number of each type is increased in proportion, as needed to cover the board.
+func (rs *Ruleset) deckFor(numPlayers int) Cards {
+	counts := make(map[CType]int, len(deckTypes))
+	total := 0
+	for _, t := range deckTypes {
+		if n := rs.Deck[t]; n > 0 {
+			counts[t] = n
+			total += n
+		}
+	}
+
+	if size := rs.layoutFor(numPlayers).Squares(); numPlayers >= largeGame && total > 0 && total < size {
+		scaled := 0
+		for t, n := range counts {
+			counts[t] = (n*size + total - 1) / total
+			scaled += counts[t]
+		}
+		total = scaled
+	}
+
+	deck := make(Cards, 0, total)
+	for len(deck) < total {
+		for _, t := range deckTypes {
+			if counts[t] > 0 {
+				counts[t]--
+				deck = append(deck, &Card{Type: t})
+			}
+		}
+	}
+	return deck
+}
+
+// startHand returns the cards with which a player begins the game.
+func (rs *Ruleset) startHand() Cards {
+	hand := make(Cards, len(rs.StartHand))
+	for i, t := range rs.StartHand {
+		hand[i] = newCard(t, true)
+	}
+	return hand
+}
+
+// StartHandString describes the starting hand, such as "2 lamps and 1 camel".
+func (rs *Ruleset) StartHandString() string {
+	var (
+		order  []CType
+		counts = make(map[CType]int)
+	)
+	for _, t := range rs.StartHand {
+		if counts[t] == 0 {
+			order = append(order, t)
+		}
+		counts[t]++
+	}
+
+	parts := make([]string, len(order))
+	for i, t := range order {
+		name := t.LString()
+		if counts[t] != 1 && !strings.HasSuffix(name, "s") {
+			name += "s"
+		}
+		parts[i] = fmt.Sprintf("%d %s", counts[t], name)
+	}
+
+	switch l := len(parts); l {
+	case 0:
+		return "no cards"
+	case 1:
+		return parts[0]
+	default:
+		return strings.Join(parts[:l-1], ", ") + " and " + parts[l-1]
+	}
+}
+
+// ruleset returns the ruleset of the game, which is the standard ruleset for games predating rulesets.
+func (s *State) ruleset() *Ruleset {
+	if s.Ruleset == nil {
+		return standardRuleset
+	}
+	return s.Ruleset
+}
+
+// value returns the point value of card c under the ruleset of the game.
+func (s *State) value(c *Card) int {
+	return s.ruleset().Value(c.Type)
+}
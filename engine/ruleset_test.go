@@ -0,0 +1,181 @@
+package engine
+
+import "testing"
+
+// testRuleset returns a copy of the standard ruleset, which may be modified.
+func testRuleset() *Ruleset {
+	rs := *Standard()
+	rs.Deck = make(map[CType]int, len(standardRuleset.Deck))
+	for t, n := range standardRuleset.Deck {
+		rs.Deck[t] = n
+	}
+	rs.StartHand = append([]CType(nil), standardRuleset.StartHand...)
+	return &rs
+}
+
+func TestRulesetValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(*Ruleset)
+		numPlayers int
+		valid      bool
+	}{
+		{"standard for two", func(*Ruleset) {}, 2, true},
+		{"standard for four", func(*Ruleset) {}, 4, true},
+		{"standard for five", func(*Ruleset) {}, 5, true},
+		{"standard for six", func(*Ruleset) {}, 6, true},
+		{"too few players", func(*Ruleset) {}, 1, false},
+		{"too many players", func(*Ruleset) {}, 7, false},
+		{"no guards", func(rs *Ruleset) {
+			delete(rs.Deck, Guard)
+		}, 4, true},
+		{"starting cards in the deck", func(rs *Ruleset) {
+			rs.Deck[StartLamp] = 2
+		}, 4, false},
+		{"negative count", func(rs *Ruleset) {
+			rs.Deck[Sword] = -1
+		}, 4, false},
+		{"deck smaller than the board", func(rs *Ruleset) {
+			rs.Deck = map[CType]int{Lamp: 8, Camel: 8}
+		}, 2, false},
+		{"empty starting hand", func(rs *Ruleset) {
+			rs.StartHand = nil
+		}, 4, false},
+		{"guard in the starting hand", func(rs *Ruleset) {
+			rs.StartHand = append(rs.StartHand, Guard)
+		}, 4, false},
+		{"board too small for the thieves", func(rs *Ruleset) {
+			rs.Layout = &Layout{Name: "small", Rows: 3, Columns: 3}
+		}, 4, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := testRuleset()
+			tt.modify(rs)
+			if err := rs.Validate(tt.numPlayers, false); (err == nil) != tt.valid {
+				t.Errorf("got error %v, want valid %t", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestDeckFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		deck       map[CType]int
+		numPlayers int
+		want       map[CType]int
+	}{
+		{"standard for two", standardRuleset.Deck, 2, standardRuleset.Deck},
+		{"standard for four", standardRuleset.Deck, 4, standardRuleset.Deck},
+		{"standard for five", standardRuleset.Deck, 5, map[CType]int{Lamp: 9, Camel: 9, Sword: 9, Carpet: 9, Coins: 9, Turban: 9, Jewels: 9, Guard: 9}},
+		{"standard for six", standardRuleset.Deck, 6, map[CType]int{Lamp: 11, Camel: 11, Sword: 11, Carpet: 11, Coins: 11, Turban: 11, Jewels: 11, Guard: 11}},
+		{"large enough for six", map[CType]int{Lamp: 45, Camel: 45}, 6, map[CType]int{Lamp: 45, Camel: 45}},
+		{"uneven for five", map[CType]int{Lamp: 3, Jewels: 1}, 5, map[CType]int{Lamp: 54, Jewels: 18}},
+		{"no guards", map[CType]int{Lamp: 10, Camel: 10, Sword: 10, Carpet: 10, Coins: 10, Turban: 10},
+			3, map[CType]int{Lamp: 10, Camel: 10, Sword: 10, Carpet: 10, Coins: 10, Turban: 10}},
+		{"uneven", map[CType]int{Lamp: 3, Jewels: 1, Guard: 0}, 2, map[CType]int{Lamp: 3, Jewels: 1}},
+		{"empty", nil, 2, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := &Ruleset{Deck: tt.deck}
+			deck := rs.deckFor(tt.numPlayers)
+
+			got := make(map[CType]int)
+			for _, c := range deck {
+				got[c.Type]++
+			}
+			if len(got) != countTypes(tt.want) {
+				t.Errorf("got cards %v, want %v", got, tt.want)
+			}
+			for ct, n := range tt.want {
+				if got[ct] != n {
+					t.Errorf("got %d %s cards, want %d", got[ct], ct, n)
+				}
+			}
+			if size := rs.layoutFor(tt.numPlayers).Squares(); tt.numPlayers >= largeGame && len(tt.deck) > 0 && len(deck) < size {
+				t.Errorf("got %d cards for a board of %d squares", len(deck), size)
+			}
+
+			// The deck deals one of each type in turn.
+			var first []CType
+			for _, ct := range deckTypes {
+				if tt.want[ct] > 0 {
+					first = append(first, ct)
+				}
+			}
+			for i, ct := range first {
+				if deck[i].Type != ct {
+					t.Errorf("card %d is a %s, want a %s", i, deck[i].Type, ct)
+				}
+			}
+		})
+	}
+}
+
+// countTypes returns the number of card types of which counts holds at least one card.
+func countTypes(counts map[CType]int) int {
+	n := 0
+	for _, c := range counts {
+		if c > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func TestStartHandString(t *testing.T) {
+	tests := []struct {
+		hand []CType
+		want string
+	}{
+		{standardRuleset.StartHand, "2 lamps and 1 camel"},
+		{nil, "no cards"},
+		{[]CType{Sword}, "1 sword"},
+		{[]CType{Coins, Coins}, "2 coins"},
+		{[]CType{Camel, Lamp, Camel, Jewels}, "2 camels, 1 lamp and 1 jewels"},
+	}
+
+	for _, tt := range tests {
+		rs := &Ruleset{StartHand: tt.hand}
+		if got := rs.StartHandString(); got != tt.want {
+			t.Errorf("%v: got %q, want %q", tt.hand, got, tt.want)
+		}
+	}
+}
+
+func TestValueFollowsRuleset(t *testing.T) {
+	rs := testRuleset()
+	rs.Values = map[CType]int{Lamp: 7}
+
+	c := &Card{Type: Lamp}
+	if got, want := (&State{}).value(c), ctypeValues[Lamp]; got != want {
+		t.Errorf("under the standard ruleset, a lamp is worth %d, want %d", got, want)
+	}
+	if got := (&State{Ruleset: rs}).value(c); got != 7 {
+		t.Errorf("under the ruleset, a lamp is worth %d, want 7", got)
+	}
+	if got := (&State{Ruleset: rs}).value(&Card{Type: Sword}); got != 0 {
+		t.Errorf("under the ruleset, an unlisted sword is worth %d, want 0", got)
+	}
+}
+
+func TestDeckForLayout(t *testing.T) {
+	// Blocking the last row of the board for six players leaves the squares of the board for five.
+	var blocked []Position
+	for col := 0; col < 9; col++ {
+		blocked = append(blocked, Position{Row: 8, Column: col})
+	}
+
+	rs := testRuleset()
+	rs.Layout = &Layout{Name: "blocked", Rows: 9, Columns: 9, Blocked: blocked}
+	if got, want := len(rs.deckFor(6)), len(Standard().deckFor(5)); got != want {
+		t.Errorf("got %d cards for a board of %d squares, want %d", got, rs.Layout.Squares(), want)
+	}
+	if err := rs.Validate(6, false); err != nil {
+		t.Errorf("validating the blocked layout for six players: %v", err)
+	}
+}
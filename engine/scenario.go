@@ -0,0 +1,226 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Scenario defines a solo puzzle: a position from which a single player, playing under the usual rules,
+// tries to reach a goal.  Card types are identified by their numeric values when encoded as JSON.
+type Scenario struct {
+	Name        string
+	Description string
+	// Cards gives the card in each square of the board, row by row.  NoType leaves a square empty.
+	Cards [][]CType
+	// Blocked lists squares that are not part of the board.
+	Blocked []Position
+	// Thieves lists the squares occupied by the player's thieves, each of which must hold a card.
+	Thieves     []Position
+	Hand        []CType
+	DrawPile    []CType
+	DiscardPile []CType
+	// Jewels is the type of the card last played, which jewels copy.
+	Jewels CType
+	// Ruleset gives the values of the cards.  Nil indicates the standard ruleset.
+	Ruleset *Ruleset
+	// Turn is the turn at which the scenario begins.  As in a game, no card is drawn at the end of turn 1.
+	// Zero indicates turn 1.
+	Turn int
+	Goal Goal
+	// Seed seeds the random stream from which cards are drawn.
+	Seed int64
+}
+
+// Goal is the score to reach within a number of turns.
+type Goal struct {
+	Points int
+	Turns  int
+}
+
+// String describes the goal, such as "reach 20 points within 5 turns".
+func (g Goal) String() string {
+	return fmt.Sprintf("reach %d points within %d turns", g.Points, g.Turns)
+}
+
+func (sc *Scenario) firstTurn() int {
+	if sc.Turn == 0 {
+		return 1
+	}
+	return sc.Turn
+}
+
+// LastTurn returns the last turn that may be played toward the goal.
+func (sc *Scenario) LastTurn() int {
+	return sc.firstTurn() + sc.Goal.Turns - 1
+}
+
+// Solved indicates whether the player identified by pid has reached the goal in state s.
+// As the game ends after the last turn of the scenario, a goal reached is reached within the turns allowed.
+func (sc *Scenario) Solved(s *State, pid int) bool {
+	p := s.PlayerByID(pid)
+	return p != nil && p.Score >= sc.Goal.Points
+}
+
+// Validate returns an error if the scenario cannot be played.
+func (sc *Scenario) Validate() error {
+	if len(sc.Cards) == 0 {
+		return errors.New("the board must have at least one row")
+	}
+
+	l := &Layout{Rows: len(sc.Cards), Columns: len(sc.Cards[0]), Blocked: sc.Blocked}
+	if err := l.Validate(1, len(sc.Thieves)); err != nil {
+		return err
+	}
+
+	for row, ts := range sc.Cards {
+		if len(ts) != l.Columns {
+			return fmt.Errorf("row %s has %d squares, but the board has %d columns", rowLabel(row), len(ts), l.Columns)
+		}
+		for _, t := range ts {
+			if t != NoType && ctypeStrings[t] == "" {
+				return fmt.Errorf("unknown card type %d", t)
+			}
+		}
+	}
+
+	if len(sc.Thieves) == 0 {
+		return errors.New("the player must have at least one thief")
+	}
+	for i, p := range sc.Thieves {
+		switch {
+		case !l.contains(p) || l.isBlocked(p):
+			return fmt.Errorf("thief at %s is off the board", p.Label())
+		case sc.Cards[p.Row][p.Column] == NoType:
+			return fmt.Errorf("thief at %s stands on an empty square", p.Label())
+		case includes(sc.Thieves[:i], p):
+			return fmt.Errorf("more than one thief is at %s", p.Label())
+		}
+	}
+
+	if len(sc.Hand) == 0 {
+		return errors.New("the hand must hold at least one card")
+	}
+	for _, ts := range [][]CType{sc.Hand, sc.DrawPile, sc.DiscardPile} {
+		for _, t := range ts {
+			if t == NoType || ctypeStrings[t] == "" {
+				return fmt.Errorf("the player may not hold %s cards", t.IDString())
+			}
+		}
+	}
+
+	switch {
+	case sc.Turn < 0:
+		return fmt.Errorf("invalid turn %d", sc.Turn)
+	case sc.Goal.Points < 1:
+		return errors.New("the goal must be at least one point")
+	case sc.Goal.Turns < 1:
+		return errors.New("the goal must allow at least one turn")
+	}
+	return nil
+}
+
+// NewState returns the state of a solo game of the scenario, played by the player identified by pid,
+// awaiting the play of a card.
+func (sc *Scenario) NewState(pid int) (*State, error) {
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+
+	p := newPlayer(pid, scenarioCards(sc.Hand, true))
+	p.DrawPile = scenarioCards(sc.DrawPile, false)
+	p.DiscardPile = scenarioCards(sc.DiscardPile, true)
+
+	s := &State{
+		Players:         []*Player{p},
+		Grid:            make(Grid, len(sc.Cards)),
+		Jewels:          Card{Type: sc.Jewels, FaceUp: true},
+		Phase:           PhasePlayCard,
+		Turn:            sc.firstTurn(),
+		Round:           1,
+		CurrentPlayerID: pid,
+		Rand:            NewRand(sc.Seed),
+		Ruleset:         sc.Ruleset,
+		LastTurn:        sc.LastTurn(),
+		BumpedPlayerID:  NoPID,
+	}
+
+	for row, ts := range sc.Cards {
+		s.Grid[row] = make(Areas, len(ts))
+		for col, t := range ts {
+			a := newArea(row, col, nil)
+			switch pos := a.Position(); {
+			case includes(sc.Blocked, pos):
+				a.Blocked = true
+			case t != NoType:
+				a.Card = newCard(t, true)
+			}
+			if includes(sc.Thieves, a.Position()) {
+				a.Thief = pid
+			}
+			s.Grid[row][col] = a
+		}
+	}
+	return s, nil
+}
+
+func scenarioCards(ts []CType, faceUp bool) Cards {
+	cs := make(Cards, len(ts))
+	for i, t := range ts {
+		cs[i] = newCard(t, faceUp)
+	}
+	return cs
+}
+
+// ScenarioFrom returns a scenario beginning from state s, at the start of a turn, with the board
+// and cards of the player identified by pid.  The thieves of other players are removed from the board,
+// leaving the cards beneath them.  The scenario is named name and has goal goal.
+func ScenarioFrom(s *State, pid int, name string, goal Goal) (*Scenario, error) {
+	p := s.PlayerByID(pid)
+	switch {
+	case p == nil:
+		return nil, fmt.Errorf("no player %d", pid)
+	case s.Phase != PhasePlayCard || s.PlayedCard != nil:
+		return nil, fmt.Errorf("a scenario must begin at the start of a turn, but the game is in the %q phase", s.Phase)
+	}
+
+	sc := &Scenario{
+		Name:        name,
+		Cards:       make([][]CType, len(s.Grid)),
+		Hand:        cardTypes(p.Hand),
+		DrawPile:    cardTypes(p.DrawPile),
+		DiscardPile: cardTypes(p.DiscardPile),
+		Jewels:      s.Jewels.Type,
+		Ruleset:     s.Ruleset,
+		Turn:        s.Turn,
+		Goal:        goal,
+		Seed:        s.Rand.Seed,
+	}
+
+	for row, as := range s.Grid {
+		sc.Cards[row] = make([]CType, len(as))
+		for col, a := range as {
+			if a.HasCard() {
+				sc.Cards[row][col] = a.Card.Type
+			}
+			if a.Blocked {
+				sc.Blocked = append(sc.Blocked, a.Position())
+			}
+			if a.Thief == pid {
+				sc.Thieves = append(sc.Thieves, a.Position())
+			}
+		}
+	}
+
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+func cardTypes(cs Cards) []CType {
+	ts := make([]CType, len(cs))
+	for i, c := range cs {
+		ts[i] = c.Type
+	}
+	return ts
+}
@@ -0,0 +1,96 @@
+package engine
+
+import "testing"
+
+// testScenario returns a valid scenario on a three by three board with an empty centre square.
+func testScenario() *Scenario {
+	return &Scenario{
+		Name: "test",
+		Cards: [][]CType{
+			{Coins, Lamp, Camel},
+			{Sword, NoType, Carpet},
+			{Turban, Coins, Lamp},
+		},
+		Thieves:  []Position{{Row: 0, Column: 0}, {Row: 2, Column: 2}},
+		Hand:     []CType{Lamp, Camel, Sword},
+		DrawPile: []CType{Carpet, Coins},
+		Goal:     Goal{Points: 5, Turns: 3},
+		Seed:     1,
+	}
+}
+
+func TestScenarioValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Scenario)
+		valid  bool
+	}{
+		{"valid", func(*Scenario) {}, true},
+		{"thief on an empty square", func(sc *Scenario) {
+			sc.Thieves[1] = Position{Row: 1, Column: 1}
+		}, false},
+		{"thieves on the same square", func(sc *Scenario) {
+			sc.Thieves[1] = sc.Thieves[0]
+		}, false},
+		{"thief off the board", func(sc *Scenario) {
+			sc.Thieves[1] = Position{Row: 3, Column: 0}
+		}, false},
+		{"thief on a blocked square", func(sc *Scenario) {
+			sc.Blocked = []Position{sc.Thieves[1]}
+		}, false},
+		{"no thieves", func(sc *Scenario) {
+			sc.Thieves = nil
+		}, false},
+		{"ragged row", func(sc *Scenario) {
+			sc.Cards[2] = sc.Cards[2][:2]
+		}, false},
+		{"empty hand", func(sc *Scenario) {
+			sc.Hand = nil
+		}, false},
+		{"empty card in the draw pile", func(sc *Scenario) {
+			sc.DrawPile = append(sc.DrawPile, NoType)
+		}, false},
+		{"no goal", func(sc *Scenario) {
+			sc.Goal.Points = 0
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := testScenario()
+			tt.modify(sc)
+
+			err := sc.Validate()
+			if valid := err == nil; valid != tt.valid {
+				t.Fatalf("got error %v, want valid %t", err, tt.valid)
+			}
+			if _, err := sc.NewState(1); (err == nil) != tt.valid {
+				t.Errorf("NewState returned error %v, want valid %t", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestScenarioPlay(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		sc := testScenario()
+		s, err := sc.NewState(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := NewRand(seed)
+		for turns := 0; s.Phase != PhaseGameOver; turns++ {
+			if turns > 100 {
+				t.Fatalf("seed %d: the scenario did not end", seed)
+			}
+			m := RandomStrategy{}.Choose(s, 1, LegalActions(s, 1), &r)
+			if s, err = PlayTurn(s, m); err != nil {
+				t.Fatalf("seed %d: playing %+v: %v", seed, m.Actions, err)
+			}
+		}
+		if s.Turn > sc.LastTurn()+1 {
+			t.Errorf("seed %d: the scenario ended on turn %d, after its last turn %d", seed, s.Turn, sc.LastTurn())
+		}
+	}
+}
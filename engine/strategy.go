@@ -0,0 +1,174 @@
+package engine
+
+// A Strategy chooses the moves of a computer controlled player.
+type Strategy interface {
+	// Choose returns one of the legal moves ms available to the player identified by pid in state s.
+	// Any randomness the strategy needs is drawn from r, never from the random stream of s.
+	Choose(s *State, pid int, ms []Move, r *Rand) Move
+}
+
+// RandomStrategy chooses uniformly among the legal moves.
+type RandomStrategy struct{}
+
+// Choose implements Strategy.
+func (RandomStrategy) Choose(s *State, pid int, ms []Move, r *Rand) Move {
+	return ms[r.Intn(len(ms))]
+}
+
+// GreedyStrategy chooses the move leading to the position of greatest value at the end of the turn,
+// choosing randomly among equally valued moves.
+// The value of a position does not depend on which cards are drawn, so the draws made while
+// looking ahead reveal nothing to the strategy.
+type GreedyStrategy struct{}
+
+// Choose implements Strategy.
+func (GreedyStrategy) Choose(s *State, pid int, ms []Move, r *Rand) Move {
+	var (
+		best  []Move
+		bestV float64
+	)
+	for _, m := range ms {
+		ns, err := PlayTurn(s, m)
+		if err != nil {
+			continue
+		}
+
+		switch v := Evaluate(ns, pid); {
+		case len(best) == 0 || v > bestV:
+			best, bestV = []Move{m}, v
+		case v == bestV:
+			best = append(best, m)
+		}
+	}
+
+	if len(best) == 0 {
+		return ms[r.Intn(len(ms))]
+	}
+	return best[r.Intn(len(best))]
+}
+
+// ExpectimaxStrategy chooses the move of greatest expected value, looking ahead to the player's following turn.
+// The expectation is taken over Samples determinizations of the hidden information: the cards drawn by
+// every player, and the face down cards held by opponents.  In each, opponents reply greedily, and the
+// player then makes the best move available.
+type ExpectimaxStrategy struct {
+	Samples int
+}
+
+// defaultSamples is the number of determinizations used by an ExpectimaxStrategy not specifying Samples.
+const defaultSamples = 6
+
+// Choose implements Strategy.
+func (es ExpectimaxStrategy) Choose(s *State, pid int, ms []Move, r *Rand) Move {
+	n := es.Samples
+	if n <= 0 {
+		n = defaultSamples
+	}
+
+	// Each move is evaluated against the same determinizations, so that
+	// differences between moves are not lost among differences in luck.
+	samples := make([]*State, n)
+	for i := range samples {
+		samples[i] = Determinize(s, pid, r)
+	}
+
+	var (
+		best  []Move
+		bestV float64
+	)
+moves:
+	for _, m := range ms {
+		total := 0.0
+		for _, d := range samples {
+			ns, err := PlayTurn(d, m)
+			if err != nil {
+				continue moves
+			}
+			total += lookAhead(ns, pid)
+		}
+
+		switch v := total / float64(n); {
+		case len(best) == 0 || v > bestV:
+			best, bestV = []Move{m}, v
+		case v == bestV:
+			best = append(best, m)
+		}
+	}
+
+	if len(best) == 0 {
+		return ms[r.Intn(len(ms))]
+	}
+	return best[r.Intn(len(best))]
+}
+
+// lookAhead returns the value of s to the player identified by pid once the opponents have replied greedily
+// and the player has made the best move available in the player's following turn.
+func lookAhead(s *State, pid int) float64 {
+	for i := 0; i < len(s.Players) && s.Phase != PhaseGameOver; i++ {
+		cp := s.CurrentPlayer()
+		if cp == nil {
+			break
+		}
+
+		ms := LegalActions(s, cp.ID)
+		if len(ms) == 0 {
+			break
+		}
+
+		ns, v, ok := firstBest(s, cp.ID, ms)
+		switch {
+		case !ok:
+			return Evaluate(s, pid)
+		case cp.ID == pid:
+			return v
+		}
+		s = ns
+	}
+	return Evaluate(s, pid)
+}
+
+// firstBest returns the position reached by the first of the moves ms of greatest value to the player
+// identified by pid, together with its value.  ok is false if none of the moves could be played.
+func firstBest(s *State, pid int, ms []Move) (best *State, bestV float64, ok bool) {
+	for _, m := range ms {
+		ns, err := PlayTurn(s, m)
+		if err != nil {
+			continue
+		}
+		if v := Evaluate(ns, pid); !ok || v > bestV {
+			best, bestV, ok = ns, v, true
+		}
+	}
+	return best, bestV, ok
+}
+
+// Determinize returns a copy of s in which the information hidden from the player identified by pid is
+// filled in at random from r: the face down cards of each opponent's hand are dealt afresh from those
+// cards and the opponent's draw pile, and all subsequent draws follow a random stream seeded from r.
+// s is not modified.
+func Determinize(s *State, pid int, r *Rand) *State {
+	d := s.Clone()
+	d.Rand = NewRand(int64(r.Uint64()))
+	for _, p := range d.Players {
+		if p.ID == pid {
+			continue
+		}
+
+		hand, pool := make(Cards, 0, len(p.Hand)), p.DrawPile
+		for _, card := range p.Hand {
+			if card.FaceUp {
+				hand = append(hand, card)
+			} else {
+				pool = append(pool, card)
+			}
+		}
+
+		for dealt := len(p.Hand) - len(hand); dealt > 0; dealt-- {
+			var card *Card
+			pool, card = pool.drawS(r)
+			hand = append(hand, card)
+		}
+		p.Hand, p.DrawPile = hand, pool
+	}
+	return d
+}
@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+// sampled returns every seventh state reached while playing a game, omitting the end of the game.
+func sampled(t *testing.T, pids []int, seed int64) []*State {
+	t.Helper()
+
+	var ss []*State
+	for i, s := range states(t, pids, false, seed) {
+		if i%7 == 0 && s.Phase != PhaseGameOver {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+func TestStrategiesChooseGivenMove(t *testing.T) {
+	strategies := []struct {
+		name string
+		s    Strategy
+	}{
+		{"random", RandomStrategy{}},
+		{"greedy", GreedyStrategy{}},
+		{"expectimax", ExpectimaxStrategy{Samples: 2}},
+	}
+
+	ss := sampled(t, []int{1, 2, 3}, 21)
+	for _, st := range strategies {
+		t.Run(st.name, func(t *testing.T) {
+			r := NewRand(22)
+			for _, s := range ss {
+				ms := LegalActions(s, s.CurrentPlayerID)
+				m := st.s.Choose(s, s.CurrentPlayerID, ms, &r)
+				if !containsMove(ms, m) {
+					t.Fatalf("chose move %+v, not among the legal moves in the %q phase", m.Actions, s.Phase)
+				}
+			}
+		})
+	}
+}
+
+func containsMove(ms []Move, m Move) bool {
+	for _, l := range ms {
+		if reflect.DeepEqual(l, m) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGreedyChoosesBestValue(t *testing.T) {
+	r := NewRand(23)
+	for _, s := range sampled(t, []int{1, 2}, 24) {
+		pid := s.CurrentPlayerID
+		ms := LegalActions(s, pid)
+		_, want, ok := firstBest(s, pid, ms)
+		if !ok {
+			continue
+		}
+
+		m := GreedyStrategy{}.Choose(s, pid, ms, &r)
+		ns, err := PlayTurn(s, m)
+		if err != nil {
+			t.Fatalf("chose move %+v failing with %v", m.Actions, err)
+		}
+		if got := Evaluate(ns, pid); got != want {
+			t.Errorf("in the %q phase, chose move %+v of value %v, want value %v", s.Phase, m.Actions, got, want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	s := New([]int{1, 2}, false, 25)
+	if v1, v2 := Evaluate(s, 1), Evaluate(s, 2); v1 != -v2 {
+		t.Errorf("got values %v and %v of the same position to two opponents", v1, v2)
+	}
+	if v := Evaluate(s, 3); v != 0 {
+		t.Errorf("got value %v to a player not in the game, want 0", v)
+	}
+
+	before := Evaluate(s, 1)
+	scored := s.Clone()
+	scored.PlayerByID(1).Score++
+	if v := Evaluate(scored, 1); v <= before {
+		t.Errorf("scoring a point changed the value from %v to %v", before, v)
+	}
+	if v := Evaluate(scored, 2); v >= -before {
+		t.Errorf("the opponent scoring a point changed the value from %v to %v", -before, v)
+	}
+}
+
+// typeCounts returns the number of cards of each type among cs.
+func typeCounts(cs ...Cards) map[CType]int {
+	counts := make(map[CType]int)
+	for _, c := range cs {
+		for _, card := range c {
+			counts[card.Type]++
+		}
+	}
+	return counts
+}
+
+func TestDeterminize(t *testing.T) {
+	r := NewRand(26)
+	for _, s := range sampled(t, []int{1, 2, 3}, 27) {
+		pid := s.CurrentPlayerID
+		orig := s.Clone()
+		d := Determinize(s, pid, &r)
+
+		if !reflect.DeepEqual(s, orig) {
+			t.Fatal("determinizing modified the state")
+		}
+		if !reflect.DeepEqual(d.PlayerByID(pid), s.PlayerByID(pid)) {
+			t.Errorf("the viewer's cards changed from %+v to %+v", s.PlayerByID(pid), d.PlayerByID(pid))
+		}
+
+		for i, p := range s.Players {
+			dp := d.Players[i]
+			switch {
+			case len(dp.Hand) != len(p.Hand) || len(dp.DrawPile) != len(p.DrawPile):
+				t.Errorf("player %d holds %d cards and draws from %d, want %d and %d",
+					p.ID, len(dp.Hand), len(dp.DrawPile), len(p.Hand), len(p.DrawPile))
+			case !reflect.DeepEqual(dp.DiscardPile, p.DiscardPile):
+				t.Errorf("player %d discarded %+v, want %+v", p.ID, dp.DiscardPile, p.DiscardPile)
+			case !reflect.DeepEqual(typeCounts(dp.Hand, dp.DrawPile), typeCounts(p.Hand, p.DrawPile)):
+				t.Errorf("player %d has cards %v, want %v", p.ID, typeCounts(dp.Hand, dp.DrawPile), typeCounts(p.Hand, p.DrawPile))
+			case !reflect.DeepEqual(typeCounts(faceUp(dp.Hand)), typeCounts(faceUp(p.Hand))):
+				t.Errorf("player %d shows cards %v, want %v", p.ID, typeCounts(faceUp(dp.Hand)), typeCounts(faceUp(p.Hand)))
+			}
+		}
+	}
+}
+
+func faceUp(cs Cards) Cards {
+	var up Cards
+	for _, card := range cs {
+		if card.FaceUp {
+			up = append(up, card)
+		}
+	}
+	return up
+}
@@ -0,0 +1,61 @@
+package engine
+
+// numTeams is the number of teams of a team game.
+const numTeams = 2
+
+// AlternateTeams divides the players identified by pids, listed in turn order, into two teams
+// seated alternately, so that partners never take consecutive turns.
+func AlternateTeams(pids []int) [][]int {
+	teams := make([][]int, numTeams)
+	for i, pid := range pids {
+		teams[i%numTeams] = append(teams[i%numTeams], pid)
+	}
+	return teams
+}
+
+// TeamOf returns the index of the team of the player identified by pid, or -1 if the player is not on a team.
+func (s *State) TeamOf(pid int) int {
+	for i, team := range s.Teams {
+		for _, id := range team {
+			if id == pid {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Partners indicates whether the players identified by pid and pid2 are the same player or on the same team.
+func (s *State) Partners(pid, pid2 int) bool {
+	if pid == pid2 {
+		return true
+	}
+	t := s.TeamOf(pid)
+	return t != -1 && t == s.TeamOf(pid2)
+}
+
+// sides returns the players grouped by team.  Without teams, each player forms a side alone.
+func (s *State) sides() [][]*Player {
+	if len(s.Teams) == 0 {
+		sides := make([][]*Player, len(s.Players))
+		for i, p := range s.Players {
+			sides[i] = []*Player{p}
+		}
+		return sides
+	}
+
+	sides := make([][]*Player, len(s.Teams))
+	for i, team := range s.Teams {
+		for _, pid := range team {
+			if p := s.PlayerByID(pid); p != nil {
+				sides[i] = append(sides[i], p)
+			}
+		}
+	}
+	return sides
+}
+
+// opposingThiefIn indicates whether area a holds the thief of a player who is not a partner of player p.
+func (s *State) opposingThiefIn(p *Player, a *Area) bool {
+	return a.HasThief() && !s.Partners(p.ID, a.Thief)
+}
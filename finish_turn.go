@@ -11,6 +11,7 @@ import (
 	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/user/stats"
+	"github.com/SlothNinja/gt/engine"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/net/context"
 )
@@ -23,19 +24,8 @@ func finish(prefix string) gin.HandlerFunc {
 		defer c.Redirect(http.StatusSeeOther, showPath(prefix, c.Param("hid")))
 
 		g := gameFrom(ctx)
-		switch g.Phase {
-		case placeThieves:
-			if err := g.placeThievesFinishTurn(ctx); err != nil {
-				log.Errorf(ctx, "g.placeThievesFinishTurn error: %v", err)
-				return
-			}
-
-		case drawCard:
-			if err := g.moveThiefFinishTurn(ctx); err != nil {
-				log.Errorf(ctx, "g.moveThiefFinishTurn error: %v", err)
-				return
-			}
-
+		if err := g.finishCurrentTurn(ctx); err != nil {
+			log.Errorf(ctx, "g.finishCurrentTurn error: %v", err)
 		}
 	}
 }
@@ -44,6 +34,18 @@ func showPath(prefix string, sid string) string {
 	return fmt.Sprintf("/%s/game/show/%s", prefix, sid)
 }
 
+// finishCurrentTurn finishes the turn of the current player in the current phase.
+func (g *Game) finishCurrentTurn(ctx context.Context) error {
+	switch g.Phase {
+	case placeThieves:
+		return g.placeThievesFinishTurn(ctx)
+	case drawCard:
+		return g.moveThiefFinishTurn(ctx)
+	default:
+		return sn.NewVError("A turn may not be finished during the %q phase.", g.PhaseName())
+	}
+}
+
 func (g *Game) validateFinishTurn(ctx context.Context) (*stats.Stats, error) {
 	log.Debugf(ctx, "Entering")
 	defer log.Debugf(ctx, "Exiting")
@@ -59,41 +61,6 @@ func (g *Game) validateFinishTurn(ctx context.Context) (*stats.Stats, error) {
 	}
 }
 
-// ps is an optional parameter.
-// If no player is provided, assume current player.
-func (g *Game) nextPlayer(ps ...game.Playerer) *Player {
-	if nper := g.NextPlayerer(ps...); nper != nil {
-		return nper.(*Player)
-	}
-	return nil
-}
-
-// ps is an optional parameter.
-// If no player is provided, assume current player.
-func (g *Game) previousPlayer(ps ...game.Playerer) *Player {
-	if nper := g.PreviousPlayerer(ps...); nper != nil {
-		return nper.(*Player)
-	}
-	return nil
-}
-
-func (g *Game) placeThievesNextPlayer(pers ...game.Playerer) (p *Player) {
-	numThieves := 3
-	if g.TwoThiefVariant {
-		numThieves = 2
-	}
-
-	p = g.previousPlayer(pers...)
-
-	if g.Round >= numThieves {
-		p = nil
-	} else if p.Equal(g.Players()[0]) {
-		g.Round++
-		p.beginningOfTurnReset()
-	}
-	return
-}
-
 func (g *Game) placeThievesFinishTurn(ctx context.Context) error {
 	log.Debugf(ctx, "Entering")
 	defer log.Debugf(ctx, "Exiting")
@@ -103,24 +70,38 @@ func (g *Game) placeThievesFinishTurn(ctx context.Context) error {
 	}
 
 	oldCP := g.CurrentPlayer()
-	np := g.placeThievesNextPlayer()
-	if np == nil {
-		g.SetCurrentPlayerers(g.Players()[0])
-		g.CurrentPlayer().beginningOfTurnReset()
-		g.startCardPlay(ctx)
-	} else {
-		g.SetCurrentPlayerers(np)
-		np.beginningOfTurnReset()
+	if err := g.finishTurn(ctx); err != nil {
+		return err
 	}
 
 	newCP := g.CurrentPlayer()
 	if newCP != nil && oldCP.ID() != newCP.ID() {
-		g.SendTurnNotificationsTo(ctx, newCP)
+		g.sendTurnNotificationsTo(ctx, newCP)
 	}
 
 	return g.save(ctx, s.GetUpdate(ctx, time.Time(g.UpdatedAt)))
 }
 
+// finishTurn ends the turn of the current player and, if the game continues, begins the turn of the next player.
+func (g *Game) finishTurn(ctx context.Context) error {
+	cp := g.CurrentPlayer()
+	if err := g.apply(ctx, engine.Action{
+		Type:     engine.FinishTurn,
+		PlayerID: cp.ID(),
+	}); err != nil {
+		return err
+	}
+	g.passClock(ctx, cp, time.Now())
+
+	if np := g.CurrentPlayer(); np != nil {
+		np.beginningOfTurnReset()
+		if g.Phase != gameOver && np.ID() != cp.ID() {
+			g.queueEvent(&gameEvent{Event: turnChanged, Player: g.NameFor(np)})
+		}
+	}
+	return nil
+}
+
 func (g *Game) validatePlaceThievesFinishTurn(ctx context.Context) (*stats.Stats, error) {
 	log.Debugf(ctx, "Entering")
 	defer log.Debugf(ctx, "Exiting")
@@ -134,23 +115,6 @@ func (g *Game) validatePlaceThievesFinishTurn(ctx context.Context) (*stats.Stats
 	}
 }
 
-func (g *Game) moveThiefNextPlayer(pers ...game.Playerer) (np *Player) {
-	cp := g.CurrentPlayer()
-	g.endOfTurnUpdateFor(cp)
-	ps := g.Players()
-	np = g.nextPlayer(pers...)
-	for !ps.allPassed() {
-		if np.Passed {
-			np = g.nextPlayer(np)
-		} else {
-			np.beginningOfTurnReset()
-			return
-		}
-	}
-	np = nil
-	return
-}
-
 func (g *Game) moveThiefFinishTurn(ctx context.Context) (err error) {
 	log.Debugf(ctx, "Entering")
 	defer log.Debugf(ctx, "Exiting")
@@ -160,50 +124,68 @@ func (g *Game) moveThiefFinishTurn(ctx context.Context) (err error) {
 	}
 
 	oldCP := g.CurrentPlayer()
-	np := g.moveThiefNextPlayer()
+	if err = g.finishTurn(ctx); err != nil {
+		return
+	}
 
 	// If no next player, end game
-	if np == nil {
-		g.finalClaim(ctx)
-		ps := g.endGame(ctx)
-		cs := contest.GenContests(ctx, ps)
-		g.Status = game.Completed
-		g.Phase = gameOver
-
-		// Need to call SendTurnNotificationsTo before saving the new contests
-		// SendEndGameNotifications relies on pulling the old contests from the db.
-		// Saving the contests resulting in double counting.
-		if err = g.sendEndGameNotifications(ctx, ps, cs); err != nil {
-			log.Warningf(ctx, err.Error())
-			err = nil
-		}
-
-		es := make([]interface{}, len(cs)+1)
-		es[0] = s.GetUpdate(ctx, time.Time(g.UpdatedAt))
-		for i, c := range cs {
-			es[i+1] = c
-		}
+	if g.Phase == gameOver {
+		es := append([]interface{}{s.GetUpdate(ctx, time.Time(g.UpdatedAt))}, g.finishGame(ctx)...)
+		return g.save(ctx, es...)
+	}
 
-		if err = g.save(ctx, es...); err != nil {
-			return
+	// Otherwise, continue moving theives.
+	if newCP := g.CurrentPlayer(); newCP != nil && oldCP.ID() != newCP.ID() {
+		if err = g.sendTurnNotificationsTo(ctx, newCP); err != nil {
+			log.Warningf(ctx, err.Error())
 		}
+	}
+	return g.save(ctx, s.GetUpdate(ctx, time.Time(g.UpdatedAt)))
+}
 
-		return
+// finishGame ends the game once the last turn is finished.
+// It returns the entities to save with the game, such as the contests updating ratings.
+func (g *Game) finishGame(ctx context.Context) []interface{} {
+	ps := g.endGame(ctx)
+	var cs contest.Contests
+	if !g.hasBots() && !g.isSolo() {
+		cs = contest.GenContests(ctx, ps)
 	}
+	g.Status = game.Completed
+	g.Phase = gameOver
 
-	// Otherwise, select next player and continue moving theives.
-	g.SetCurrentPlayerers(np)
-	if np.Equal(g.Players()[0]) {
-		g.Turn++
+	// The analysis is kept with the game, so that it is not replayed on every view.
+	if a, err := g.analyze(); err != nil {
+		log.Warningf(ctx, "unable to analyze game %d: %v", g.ID, err)
+	} else {
+		g.Analysis = a
 	}
-	g.Phase = playCard
 
-	if newCP := g.CurrentPlayer(); newCP != nil && oldCP.ID() != newCP.ID() {
-		if err = g.SendTurnNotificationsTo(ctx, newCP); err != nil {
+	// Need to call SendTurnNotificationsTo before saving the new contests
+	// SendEndGameNotifications relies on pulling the old contests from the db.
+	// Saving the contests resulting in double counting.
+	var rs results
+	if !g.isSolo() {
+		var err error
+		if rs, err = g.endGameResults(ctx, ps, cs); err != nil {
+			log.Warningf(ctx, err.Error())
+		}
+		if err = g.sendEndGameNotifications(ctx, rs); err != nil {
 			log.Warningf(ctx, err.Error())
 		}
 	}
-	return g.save(ctx, s.GetUpdate(ctx, time.Time(g.UpdatedAt)))
+	g.queueEvent(g.endedEvent(rs))
+
+	es := make([]interface{}, len(cs))
+	for i, c := range cs {
+		es[i] = c
+	}
+	if g.isSolo() {
+		if b := g.bestScoreUpdate(ctx); b != nil {
+			es = append(es, b)
+		}
+	}
+	return es
 }
 
 func (g *Game) validateMoveThiefFinishTurn(ctx context.Context) (*stats.Stats, error) {
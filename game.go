@@ -6,20 +6,21 @@ import (
 	"html/template"
 	"reflect"
 	"strconv"
+	"time"
 
-	"bitbucket.org/SlothNinja/slothninja-games/sn"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/schema"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/type"
+	"github.com/SlothNinja/gt/engine"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/net/context"
 )
 
 func init() {
-	gob.Register(new(setupEntry))
-	gob.Register(new(startEntry))
+	registerEntry("setup", new(setupEntry))
+	registerEntry("start", new(startEntry))
 }
 
 // Register assigns a game type and routes.
@@ -37,6 +38,9 @@ const noPID = game.NoPlayerID
 type Game struct {
 	*game.Header
 	*State
+
+	// events are the events awaiting delivery to webhooks once the game is stored.
+	events []*gameEvent
 }
 
 // State stores the game state.
@@ -46,6 +50,33 @@ type State struct {
 	Grid            grid
 	Jewels          Card
 	TwoThiefVariant bool `form:"two-thief-variant"`
+	// Bots is the number of seats filled by computer controlled players.
+	Bots int `form:"bots"`
+	// BotLevel is the level, easy, medium, hard or expert, at which the bots play.
+	BotLevel string `form:"bot-level"`
+	// Rand is the game's own source of randomness, persisted so that the grid
+	// and every draw can be reproduced from its seed and the moves made.
+	Rand engine.Rand
+	// Ruleset defines the cards of the game.  Nil indicates the standard ruleset.
+	Ruleset *engine.Ruleset `form:"-"`
+	// TeamPlay divides the players into two teams, whose members sum their scores.
+	TeamPlay bool `form:"team-play"`
+	// Teams lists the player ids of the members of each team, assigned when the game starts.
+	Teams [][]int `form:"-"`
+	// Scenario defines the position and goal of a solo game.  Nil indicates a game between players.
+	Scenario *engine.Scenario `form:"-"`
+	// TimeControl limits the time players may take: "move" limits each turn, and "bank" the total of each player's turns.
+	// Empty indicates no limit.
+	TimeControl string `form:"time-control"`
+	// TimeLimit is the number of hours allowed each turn, or banked by each player.
+	TimeLimit int `form:"time-limit"`
+	// TimeoutAction is the action taken for a player whose turn times out: "pass", the default, or "bot".
+	TimeoutAction string `form:"timeout-action"`
+	// ClockStarted is when the clock of the current player was started.  Zero indicates no clock is running.
+	ClockStarted time.Time `form:"-"`
+	// Analysis compares the moves made with the best-evaluated moves, once the game is over.
+	// Nil indicates a game in progress, or one finished before analyses were kept.
+	Analysis *analysis `form:"-"`
 	*TempData
 }
 
@@ -62,6 +93,8 @@ type TempData struct {
 	SelectedThiefAreaF *Area
 	ClickAreas         areas
 	Admin              string
+	// SavedLogLength is the length of the game log as last saved, recorded when the saved state is decoded.
+	SavedLogLength int
 }
 
 // GetPlayerers implements the GetPlayerers interfaces of the sn/games package.
@@ -100,7 +133,12 @@ type Games []*Game
 // Start begins a Guild of Thieves game.
 func (g *Game) Start(ctx context.Context) error {
 	g.Status = game.Running
-	return g.setupPhase(ctx)
+	if err := g.setupPhase(ctx); err != nil {
+		return err
+	}
+	g.startClocks(ctx, time.Now())
+	g.queueEvent(&gameEvent{Event: gameStarted})
+	return nil
 }
 
 func (g *Game) addNewPlayers() {
@@ -109,17 +147,35 @@ func (g *Game) addNewPlayers() {
 	}
 }
 
+// playerIDs returns the ids of the players in turn order.
+func (g *Game) playerIDs() []int {
+	ps := g.Players()
+	pids := make([]int, len(ps))
+	for i, p := range ps {
+		pids[i] = p.ID()
+	}
+	return pids
+}
+
 func (g *Game) setupPhase(ctx context.Context) error {
 	g.Turn = 0
 	g.Phase = setup
+	g.NumPlayers += g.Bots
 	g.addNewPlayers()
+	if g.isSolo() {
+		return g.setupScenario(ctx)
+	}
+	g.addBotPlayers()
 	g.RandomTurnOrder()
-	g.createGrid()
+	s := engine.NewWithRuleset(g.playerIDs(), g.TwoThiefVariant, g.Ruleset, time.Now().UnixNano())
+	if g.TeamPlay {
+		s.Teams = engine.AlternateTeams(g.playerIDs())
+	}
+	g.setEngineState(s)
+	g.Phase = setup
 	for _, p := range g.Players() {
 		g.newSetupEntryFor(p)
 	}
-	cp := g.previousPlayer(g.Players()[0])
-	g.setCurrentPlayers(cp)
 	g.beginningOfPhaseReset()
 	return g.start(ctx)
 }
@@ -137,7 +193,7 @@ func (g *Game) newSetupEntryFor(p *Player) (e *setupEntry) {
 }
 
 func (e *setupEntry) HTML(g *Game) template.HTML {
-	return restful.HTML("%s received 2 lamps and 1 camel.", g.NameByPID(e.PlayerID))
+	return restful.HTML("%s received %s.", g.NameByPID(e.PlayerID), g.ruleset().StartHandString())
 }
 
 func (g *Game) start(ctx context.Context) error {
@@ -148,11 +204,21 @@ func (g *Game) start(ctx context.Context) error {
 
 type startEntry struct {
 	*Entry
+	PlayerIDs       []int
+	TwoThiefVariant bool
+	Seed            int64
+	Ruleset         *engine.Ruleset
+	Teams           [][]int
 }
 
 func (g *Game) newStartEntry() *startEntry {
 	e := new(startEntry)
 	e.Entry = g.newEntry()
+	e.PlayerIDs = g.playerIDs()
+	e.TwoThiefVariant = g.TwoThiefVariant
+	e.Seed = g.Rand.Seed
+	e.Ruleset = g.Ruleset
+	e.Teams = g.Teams
 	g.Log = append(g.Log, e)
 	return e
 }
@@ -212,20 +278,6 @@ func (g *Game) PlayerByUserID(id int64) (player *Player) {
 //	return
 //}
 
-func (g *Game) undoTurn(ctx context.Context) (string, game.ActionType, error) {
-	log.Debugf(ctx, "Entering")
-	defer log.Debugf(ctx, "Exiting")
-
-	if !g.CUserIsCPlayerOrAdmin(ctx) {
-		return "", game.None, sn.NewVError("Only the current player may perform this action.")
-	}
-
-	if cp := g.CurrentPlayer(); cp != nil {
-		restful.AddNoticef(ctx, "%s undid turn.", g.NameFor(cp))
-	}
-	return "", game.Undo, nil
-}
-
 // CurrentPlayer returns the player whose turn it is.
 func (g *Game) CurrentPlayer() (player *Player) {
 	if p := g.CurrentPlayerer(); p != nil {
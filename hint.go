@@ -0,0 +1,152 @@
+package got
+
+import (
+	"net/http"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"github.com/SlothNinja/gt/engine"
+	"github.com/gin-gonic/gin"
+)
+
+// moveView is the JSON representation of a ranked move.
+type moveView struct {
+	Card   string   `json:"card,omitempty"`
+	As     string   `json:"as,omitempty"`
+	Thief  string   `json:"thief,omitempty"`
+	To     []string `json:"to,omitempty"`
+	Pass   bool     `json:"pass,omitempty"`
+	Points int      `json:"points"`
+	Value  float64  `json:"value"`
+}
+
+func newMoveView(rm engine.RankedMove) *moveView {
+	v := &moveView{Points: rm.Points, Value: rm.Value}
+	if len(rm.Actions) == 1 && rm.Actions[0].Type == engine.Pass {
+		v.Pass = true
+	}
+	if rm.Card != engine.NoType {
+		v.Card = rm.Card.IDString()
+	}
+	if rm.As != rm.Card {
+		v.As = rm.As.IDString()
+	}
+	if rm.Thief != nil {
+		v.Thief = rm.Thief.Label()
+	}
+	for _, p := range rm.To {
+		v.To = append(v.To, p.Label())
+	}
+	return v
+}
+
+func moveViews(rms []engine.RankedMove) []*moveView {
+	vs := make([]*moveView, len(rms))
+	for i, rm := range rms {
+		vs[i] = newMoveView(rm)
+	}
+	return vs
+}
+
+// hint responds with the legal moves of the current player ranked from best to worst.
+func hint(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	g := gameFrom(ctx)
+	if g == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		return
+	}
+
+	cp := g.CurrentPlayer()
+	if cp == nil || !g.CUserIsCPlayerOrAdmin(ctx) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the current player may ask for a hint."})
+		return
+	}
+
+	s := g.engineState()
+	c.JSON(http.StatusOK, gin.H{
+		"version": apiVersion,
+		"moves":   moveViews(engine.Rank(s, cp.ID(), engine.LegalActions(s, cp.ID()))),
+	})
+}
+
+// analysisTolerance is the least loss in value for a move to differ from the best-evaluated move.
+const analysisTolerance = 1e-6
+
+// analysis compares the moves made in a finished game with the best-evaluated moves.
+type analysis struct {
+	Players     []*playerAnalysis `json:"players"`
+	Differences []*turnAnalysis   `json:"differences,omitempty"`
+}
+
+// playerAnalysis summarizes how often a player made the best-evaluated move and the value lost otherwise.
+type playerAnalysis struct {
+	PlayerID int     `json:"playerId"`
+	Name     string  `json:"name"`
+	Turns    int     `json:"turns"`
+	Best     int     `json:"best"`
+	Loss     float64 `json:"loss"`
+}
+
+// turnAnalysis describes a turn in which the move made differed from the best-evaluated move.
+type turnAnalysis struct {
+	PlayerID int       `json:"playerId"`
+	Name     string    `json:"name"`
+	Turn     int       `json:"turn"`
+	Phase    string    `json:"phase"`
+	Best     *moveView `json:"best"`
+	Loss     float64   `json:"loss"`
+}
+
+// analyze replays the game, evaluating the moves available at the start of each turn.
+// As the value of a position does not depend on the cards drawn, the value of the move made
+// is given by the position at the end of the turn.
+func (g *Game) analyze() (*analysis, error) {
+	r, err := g.replay(len(g.Log) - 1)
+	if err != nil {
+		return nil, err
+	}
+
+	a := new(analysis)
+	players := make(map[int]*playerAnalysis)
+	for _, p := range g.Players() {
+		pa := &playerAnalysis{PlayerID: p.ID(), Name: g.NameFor(p)}
+		players[pa.PlayerID] = pa
+		a.Players = append(a.Players, pa)
+	}
+
+	for i := 0; i+1 < len(r.turns); i++ {
+		s, next := r.turns[i], r.turns[i+1]
+		cp := s.CurrentPlayer()
+		if cp == nil || players[cp.ID] == nil {
+			continue
+		}
+
+		rms := engine.Rank(s, cp.ID, engine.LegalActions(s, cp.ID))
+		if len(rms) == 0 {
+			continue
+		}
+
+		pa := players[cp.ID]
+		pa.Turns++
+		loss := rms[0].Value - (engine.Evaluate(next, cp.ID) - engine.Evaluate(s, cp.ID))
+		if loss < analysisTolerance {
+			pa.Best++
+			continue
+		}
+
+		pa.Loss += loss
+		a.Differences = append(a.Differences, &turnAnalysis{
+			PlayerID: cp.ID,
+			Name:     pa.Name,
+			Turn:     s.Turn,
+			Phase:    s.Phase.String(),
+			Best:     newMoveView(rms[0]),
+			Loss:     loss,
+		})
+	}
+	return a, nil
+}
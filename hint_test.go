@@ -0,0 +1,52 @@
+package got
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	pg := playGame(t, 3, 5)
+	a, err := pg.analyze()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a.Players) != 3 {
+		t.Fatalf("got %d players, want 3", len(a.Players))
+	}
+
+	differences := make(map[int]int)
+	for _, ta := range a.Differences {
+		differences[ta.PlayerID]++
+		if ta.Loss < analysisTolerance || ta.Best == nil {
+			t.Errorf("turn %d of player %d differs by %v from best move %+v", ta.Turn, ta.PlayerID, ta.Loss, ta.Best)
+		}
+	}
+	for _, pa := range a.Players {
+		if pa.Turns == 0 || pa.Best+differences[pa.PlayerID] != pa.Turns {
+			t.Errorf("player %d made the best move on %d of %d turns, and another on %d", pa.PlayerID, pa.Best, pa.Turns, differences[pa.PlayerID])
+		}
+	}
+
+	// The analysis is kept with the saved state, in either encoding.
+	defer SetStateEncoding(stateEncoding)
+	pg.Analysis = a
+	for _, enc := range []string{GobEncoding, JSONEncoding} {
+		if err := SetStateEncoding(enc); err != nil {
+			t.Fatal(err)
+		}
+
+		v, err := encodeState(pg.State)
+		if err != nil {
+			t.Fatalf("%s: %v", enc, err)
+		}
+		s, _, err := decodeState(v)
+		if err != nil {
+			t.Fatalf("%s: %v", enc, err)
+		}
+		if !reflect.DeepEqual(s.Analysis, a) {
+			t.Errorf("%s: got analysis %+v, want %+v", enc, s.Analysis, a)
+		}
+	}
+}
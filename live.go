@@ -0,0 +1,393 @@
+package got
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"github.com/gin-gonic/gin"
+	"go.chromium.org/gae/service/memcache"
+	"golang.org/x/net/context"
+)
+
+// Update describes a saved change to a game pushed to subscribers.
+// Published updates hold only what every viewer may see.
+type Update struct {
+	GameID          int64        `json:"gameId"`
+	Phase           string       `json:"phase"`
+	Turn            int          `json:"turn"`
+	Round           int          `json:"round"`
+	CurrentPlayerID int          `json:"currentPlayerId"`
+	UpdatedAt       time.Time    `json:"updatedAt"`
+	Entries         []*entryView `json:"entries"`
+	// Game is the saved game as seen by the subscriber.  It is added to the update sent to each subscriber,
+	// so that hands and draw piles reach only the viewers allowed to see them.
+	Game *gameView `json:"game,omitempty"`
+}
+
+// Broker delivers game updates to subscribers.
+// Subscribe returns a channel of updates for the game and a function that ends the subscription.
+type Broker interface {
+	Publish(ctx context.Context, u *Update) error
+	Subscribe(ctx context.Context, gid int64) (<-chan *Update, func(), error)
+}
+
+var broker Broker = memcacheBroker{}
+
+// SetBroker replaces the broker used to deliver game updates.
+func SetBroker(b Broker) {
+	broker = b
+}
+
+// Limits of the updates kept by memcacheBroker.
+const (
+	liveBacklog    = 16
+	liveExpiration = 10 * time.Minute
+	livePoll       = time.Second
+)
+
+// memcacheBroker delivers game updates through memcache, so that they reach subscribers served by any instance,
+// such as those watching a game whose bots move in a task.  The recent updates of each game are kept under a key
+// polled by each subscription.
+type memcacheBroker struct{}
+
+// liveRecord is the record kept in memcache of the recent updates of a game.
+type liveRecord struct {
+	// Seq numbers the last update.  The updates before it are numbered consecutively.
+	Seq     int64     `json:"seq"`
+	Updates []*Update `json:"updates"`
+}
+
+func liveKey(gid int64) string {
+	return fmt.Sprintf("got-live-%d", gid)
+}
+
+// record returns the memcache item holding the recent updates of the game, and the updates.
+// A missing or unreadable record is returned empty with a new item.
+func (memcacheBroker) record(ctx context.Context, gid int64) (memcache.Item, *liveRecord, bool, error) {
+	item, err := memcache.GetKey(ctx, liveKey(gid))
+	switch {
+	case err == memcache.ErrCacheMiss:
+		return memcache.NewItem(ctx, liveKey(gid)), new(liveRecord), false, nil
+	case err != nil:
+		return nil, nil, false, err
+	}
+
+	rec := new(liveRecord)
+	if err = json.Unmarshal(item.Value(), rec); err != nil {
+		log.Warningf(ctx, "discarding live updates of game %d: %v", gid, err)
+		return item, new(liveRecord), true, nil
+	}
+	return item, rec, true, nil
+}
+
+// Publish adds u to the recent updates of the game, retrying should another request publish concurrently.
+func (b memcacheBroker) Publish(ctx context.Context, u *Update) error {
+	for attempt := 0; attempt < 3; attempt++ {
+		item, rec, found, err := b.record(ctx, u.GameID)
+		if err != nil {
+			return err
+		}
+
+		rec.Seq++
+		rec.Updates = append(rec.Updates, u)
+		if l := len(rec.Updates); l > liveBacklog {
+			rec.Updates = rec.Updates[l-liveBacklog:]
+		}
+
+		v, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		item.SetValue(v).SetExpiration(liveExpiration)
+
+		if found {
+			err = memcache.CompareAndSwap(ctx, item)
+		} else {
+			err = memcache.Add(ctx, item)
+		}
+		switch err {
+		case nil:
+			return nil
+		case memcache.ErrCASConflict, memcache.ErrNotStored:
+			continue
+		default:
+			return err
+		}
+	}
+	return fmt.Errorf("unable to publish update of game %d: too many concurrent updates", u.GameID)
+}
+
+// Subscribe polls the recent updates of the game, sending those published after the subscription.
+func (b memcacheBroker) Subscribe(ctx context.Context, gid int64) (<-chan *Update, func(), error) {
+	_, rec, _, err := b.record(ctx, gid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ch, done := make(chan *Update, liveBacklog), make(chan struct{})
+	go func(last int64) {
+		defer close(ch)
+
+		ticker := time.NewTicker(livePoll)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			_, rec, _, err := b.record(ctx, gid)
+			if err != nil {
+				log.Warningf(ctx, "unable to poll live updates of game %d: %v", gid, err)
+				continue
+			}
+
+			// A record that expired and began again is numbered afresh.
+			if rec.Seq < last {
+				last = 0
+			}
+			first := rec.Seq - int64(len(rec.Updates)) + 1
+			for i, u := range rec.Updates {
+				if first+int64(i) <= last {
+					continue
+				}
+				select {
+				case ch <- u:
+				default:
+					log.Warningf(ctx, "dropped update for game %d", gid)
+				}
+			}
+			last = rec.Seq
+		}
+	}(rec.Seq)
+
+	var once sync.Once
+	return ch, func() { once.Do(func() { close(done) }) }, nil
+}
+
+// memBroker is an in-process Broker, reaching only subscribers served by the same instance.
+type memBroker struct {
+	sync.Mutex
+	subs map[int64]map[chan *Update]bool
+}
+
+// NewMemoryBroker returns a Broker reaching subscribers served by the same instance, for use in tests and local development.
+func NewMemoryBroker() Broker {
+	return &memBroker{subs: make(map[int64]map[chan *Update]bool)}
+}
+
+// Publish delivers u to each subscriber of the game, dropping it for subscribers that are not keeping up.
+func (b *memBroker) Publish(ctx context.Context, u *Update) error {
+	b.Lock()
+	defer b.Unlock()
+
+	for ch := range b.subs[u.GameID] {
+		select {
+		case ch <- u:
+		default:
+			log.Warningf(ctx, "dropped update for game %d", u.GameID)
+		}
+	}
+	return nil
+}
+
+// Subscribe registers a subscriber for updates of the game.
+func (b *memBroker) Subscribe(ctx context.Context, gid int64) (<-chan *Update, func(), error) {
+	b.Lock()
+	defer b.Unlock()
+
+	ch := make(chan *Update, 16)
+	if b.subs[gid] == nil {
+		b.subs[gid] = make(map[chan *Update]bool)
+	}
+	b.subs[gid][ch] = true
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.Lock()
+			defer b.Unlock()
+
+			delete(b.subs[gid], ch)
+			if len(b.subs[gid]) == 0 {
+				delete(b.subs, gid)
+			}
+			close(ch)
+		})
+	}
+	return ch, cancel, nil
+}
+
+// savedLogLength returns the length of the game log as last saved.  It is recorded when the saved state
+// is decoded, so the saved state is decoded again only for a game saved earlier in the request.
+func (g *Game) savedLogLength() (int, error) {
+	if g.TempData != nil {
+		return g.SavedLogLength, nil
+	}
+
+	if len(g.SavedState) == 0 {
+		return 0, nil
+	}
+
+	s, _, err := decodeState(g.SavedState)
+	if err != nil {
+		return 0, err
+	}
+	return len(s.Log), nil
+}
+
+// publish sends subscribers the current phase and player of the game, and the log entries following entry n.
+func (g *Game) publish(ctx context.Context, n int) {
+	u := &Update{
+		GameID:          g.ID,
+		Phase:           g.PhaseName(),
+		Turn:            g.Turn,
+		Round:           g.Round,
+		CurrentPlayerID: noPID,
+		UpdatedAt:       time.Time(g.UpdatedAt),
+		Entries:         []*entryView{},
+	}
+
+	if cp := g.CurrentPlayer(); cp != nil {
+		u.CurrentPlayerID = cp.ID()
+	}
+
+	if n >= 0 && n < len(g.Log) {
+		u.Entries = g.entryViews(g.Log[n:])
+	}
+
+	if err := broker.Publish(ctx, u); err != nil {
+		log.Warningf(ctx, "broker.Publish error: %v", err)
+	}
+}
+
+// keepAlive is the interval at which an idle subscription is sent a ping.
+const keepAlive = 30 * time.Second
+
+// live streams updates of the game to the client as Server-Sent Events.
+func live(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	g := gameFrom(ctx)
+	if g == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		return
+	}
+
+	updates, cancel, err := broker.Subscribe(ctx, g.ID)
+	if err != nil {
+		log.Errorf(ctx, "broker.Subscribe error: %v", err)
+		c.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	defer cancel()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case u, ok := <-updates:
+			if !ok {
+				return false
+			}
+			c.SSEvent("update", viewerUpdate(ctx, u))
+			return true
+		case <-ticker.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// viewerUpdate returns a copy of update u adding the saved game as seen by the current user.
+// If the game cannot be retrieved, u is returned as published.
+func viewerUpdate(ctx context.Context, u *Update) *Update {
+	g, err := liveGames.get(ctx, u.GameID, u.UpdatedAt)
+	if err != nil {
+		log.Warningf(ctx, "unable to get game %d for live update: %v", u.GameID, err)
+		return u
+	}
+
+	vu := *u
+	vu.Game = g.viewFor(ctx)
+	return &vu
+}
+
+// liveGameExpiration is how long a game loaded for live updates is kept once no longer used.
+const liveGameExpiration = 10 * time.Minute
+
+// liveGames holds the games loaded for live updates, so that the projections sent to the subscribers
+// of an update are made from one decoded game.
+var liveGames = newSavedGames(loadSaved)
+
+// savedGames holds the last saved version of games, loading each version once.
+type savedGames struct {
+	sync.Mutex
+	load  func(ctx context.Context, gid int64) (*Game, error)
+	games map[int64]*savedGame
+}
+
+// savedGame is a game as saved at updatedAt.  Once loaded, it must not be modified.
+type savedGame struct {
+	updatedAt time.Time
+	used      time.Time
+	once      sync.Once
+	g         *Game
+	err       error
+}
+
+func newSavedGames(load func(ctx context.Context, gid int64) (*Game, error)) *savedGames {
+	return &savedGames{load: load, games: make(map[int64]*savedGame)}
+}
+
+// get returns the game having id gid as saved at updatedAt, loading it unless already loaded.
+func (gs *savedGames) get(ctx context.Context, gid int64, updatedAt time.Time) (*Game, error) {
+	now := time.Now()
+
+	gs.Lock()
+	for id, sg := range gs.games {
+		if now.Sub(sg.used) > liveGameExpiration {
+			delete(gs.games, id)
+		}
+	}
+	sg := gs.games[gid]
+	if sg == nil || !sg.updatedAt.Equal(updatedAt) {
+		sg = &savedGame{updatedAt: updatedAt}
+		gs.games[gid] = sg
+	}
+	sg.used = now
+	gs.Unlock()
+
+	sg.once.Do(func() { sg.g, sg.err = gs.load(ctx, gid) })
+	return sg.g, sg.err
+}
+
+// loadSaved returns the game having id gid as saved.
+func loadSaved(ctx context.Context, gid int64) (*Game, error) {
+	g := New(ctx)
+	g.ID = gid
+	if err := store.Get(ctx, g); err != nil {
+		return nil, err
+	}
+
+	if err := g.loadState(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := g.init(ctx); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
@@ -0,0 +1,103 @@
+package got
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"golang.org/x/net/context"
+)
+
+func TestSavedGames(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		loads int
+	)
+	gs := newSavedGames(func(ctx context.Context, gid int64) (*Game, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		loads++
+		if gid == 0 {
+			return nil, errors.New("no such game")
+		}
+		return &Game{Header: &game.Header{ID: gid}}, nil
+	})
+
+	ctx := context.Background()
+	saved := day(0, 0)
+	got := make([]*Game, 8)
+	var wg sync.WaitGroup
+	for i := range got {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i], _ = gs.get(ctx, 1, saved)
+		}(i)
+	}
+	wg.Wait()
+
+	if loads != 1 {
+		t.Errorf("the subscribers of an update loaded the game %d times, want once", loads)
+	}
+	for i, g := range got {
+		if g == nil || g != got[0] {
+			t.Fatalf("subscriber %d got game %p, want the shared game %p", i, g, got[0])
+		}
+	}
+
+	// A later save is loaded afresh.
+	if g, err := gs.get(ctx, 1, saved.Add(time.Minute)); err != nil || g == got[0] || loads != 2 {
+		t.Errorf("after saving: got game %p and error %v after %d loads", g, err, loads)
+	}
+
+	if _, err := gs.get(ctx, 0, saved); err == nil {
+		t.Error("getting a missing game succeeded")
+	}
+}
+
+func TestViewerUpdate(t *testing.T) {
+	g := testViewGame(t)
+	old := liveGames
+	defer func() { liveGames = old }()
+	liveGames = newSavedGames(func(context.Context, int64) (*Game, error) { return g, nil })
+
+	u := &Update{GameID: g.ID, UpdatedAt: day(0, 0)}
+	vu := viewerUpdate(context.Background(), u)
+	switch {
+	case vu == u:
+		t.Fatal("the update was sent as published")
+	case u.Game != nil:
+		t.Error("the published update was given the viewer's game")
+	case vu.Game == nil || vu.Game.Full:
+		t.Errorf("the viewer got game %+v, want the redacted game", vu.Game)
+	}
+}
+
+func TestSavedLogLength(t *testing.T) {
+	g := testViewGame(t)
+	g.newStartEntry()
+	g.newEndGameEntry()
+
+	v, err := encodeState(g.State)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := &Game{Header: &game.Header{ID: g.ID, SavedState: v}}
+	if err := loaded.loadState(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Entries logged since loading are not counted.
+	loaded.newEndGameEntry()
+	if n, err := loaded.savedLogLength(); err != nil || n != 2 {
+		t.Errorf("got saved log length %d and error %v, want 2", n, err)
+	}
+
+	// Once encoded, the length is found from the saved state.
+	loaded.TempData = nil
+	if n, err := loaded.savedLogLength(); err != nil || n != 2 {
+		t.Errorf("without temporary data: got saved log length %d and error %v, want 2", n, err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"github.com/SlothNinja/gt/engine"
 )
 
 // Entry stores information about a move in the game log.
@@ -43,6 +44,25 @@ func (g *Game) newEntryFor(p *Player) (e *Entry) {
 	return
 }
 
+// newEntryFromEvent returns an entry for the player, turn, and phase recorded by a rules engine event.
+func (g *Game) newEntryFromEvent(ev engine.Event) (e *Entry) {
+	b := ev.Base()
+	e = g.newEntry()
+	e.PlayerID = b.PlayerID
+	e.TurnF = b.Turn
+	e.PhaseF = game.Phase(b.Phase)
+	e.RoundF = b.Round
+	return
+}
+
+// addEntry appends e to the game log and, if pid identifies a player, to the player's log.
+func (g *Game) addEntry(pid int, e Entryer) {
+	if p := g.PlayerByID(pid); p != nil {
+		p.Log = append(p.Log, e)
+	}
+	g.Log = append(g.Log, e)
+}
+
 // PhaseName displays the turn and phase in an entry of the game log.
 func (e *Entry) PhaseName() string {
 	return fmt.Sprintf("Turn %d | Phase: %s", e.Turn(), phaseNames[e.Phase()])
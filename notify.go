@@ -0,0 +1,316 @@
+package got
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/send"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/user"
+	"github.com/gin-gonic/gin"
+	"go.chromium.org/gae/service/datastore"
+	"go.chromium.org/gae/service/mail"
+	"golang.org/x/net/context"
+)
+
+const notificationSender = "webmaster@slothninja.com"
+
+// Notifier sends notification email to players.
+type Notifier interface {
+	Notify(ctx context.Context, ms ...*mail.Message) error
+}
+
+// mailNotifier sends notification email using the App Engine mail service.
+type mailNotifier struct{}
+
+func (mailNotifier) Notify(ctx context.Context, ms ...*mail.Message) error {
+	return send.Message(ctx, ms...)
+}
+
+var notifier Notifier = mailNotifier{}
+
+// SetNotifier replaces the notifier used to send notification email.
+func SetNotifier(n Notifier) {
+	notifier = n
+}
+
+var reminderThreshold = 48 * time.Hour
+
+// SetReminderThreshold sets how long a turn may wait before the current player is reminded of it,
+// and how long between further reminders.
+func SetReminderThreshold(d time.Duration) {
+	reminderThreshold = d
+}
+
+// reminder records when the current player of a game was last reminded of the player's turn.
+// It is keyed by the id of the game.
+type reminder struct {
+	Kind string `gae:"$kind,GOTReminder"`
+	ID   int64  `gae:"$id"`
+	Sent time.Time
+}
+
+// waitingSince returns when the game last changed, from which the current player has been awaited.
+func (g *Game) waitingSince() time.Time {
+	return time.Time(g.UpdatedAt)
+}
+
+// awaiting returns the player whose turn the game awaits, or nil if the game awaits no one who
+// may be sent email: a bot or a player on vacation.
+func (g *Game) awaiting(ctx context.Context, now time.Time) *Player {
+	// Bots are skipped before their vacations are sought.
+	if cp := g.CurrentPlayer(); cp != nil && !cp.IsBot() && awaits(cp, cp.User(), vacationsOf(ctx, cp.User(), now), now) {
+		return cp
+	}
+	return nil
+}
+
+// awaits indicates whether player p, controlled by user u having vacations vs, may be sent email of a turn at time now.
+func awaits(p *Player, u *user.User, vs vacations, now time.Time) bool {
+	if p == nil || p.IsBot() || u == nil {
+		return false
+	}
+
+	for _, v := range vs {
+		if v.includes(now) {
+			return false
+		}
+	}
+	return true
+}
+
+// reminderDue returns the reminder to record if the current player is due a reminder at time now.
+func (g *Game) reminderDue(ctx context.Context, now time.Time) (*reminder, bool) {
+	if g.awaiting(ctx, now) == nil {
+		return nil, false
+	}
+
+	r := &reminder{ID: g.ID}
+	switch err := datastore.Get(ctx, r); {
+	case err == datastore.ErrNoSuchEntity:
+	case err != nil:
+		log.Warningf(ctx, "unable to get reminder for game %d: %v", g.ID, err)
+		return nil, false
+	}
+
+	if !r.due(g.waitingSince(), now) {
+		return nil, false
+	}
+	r.Sent = now
+	return r, true
+}
+
+// due indicates whether a reminder is due at time now of a turn awaited since time since:
+// the turn has waited at least the threshold since then and since the reminder was last sent.
+func (r *reminder) due(since, now time.Time) bool {
+	if r.Sent.After(since) {
+		since = r.Sent
+	}
+	return now.Sub(since) >= reminderThreshold
+}
+
+// reminderTemplate and digestTemplate format reminders and digests without the templates
+// got/turn_reminder_notification and got/digest_notification.
+var reminderTemplate = template.Must(template.New("reminder").Parse(`<p>{{.Name}},</p>
+<p>Guild of Thieves #{{.ID}}, {{.Title}}, has awaited your turn for {{.Waiting}}.</p>`))
+
+var digestTemplate = template.Must(template.New("digest").Parse(`<p>{{.Name}},</p>
+<p>The following Guild of Thieves games await your turn:</p>
+<ul>{{range .Games}}
+<li>#{{.ID}}, {{.Title}}, for {{.Waiting}}</li>{{end}}
+</ul>`))
+
+// waitingView describes a game awaiting a player, for notification email.
+type waitingView struct {
+	ID      int64
+	Title   string
+	Waiting string
+}
+
+func (g *Game) waitingView(now time.Time) waitingView {
+	return waitingView{
+		ID:      g.ID,
+		Title:   g.Title,
+		Waiting: waitingString(now.Sub(g.waitingSince())),
+	}
+}
+
+// waitingString describes a wait in whole days, or hours when less than a day.
+func waitingString(d time.Duration) string {
+	days, hours := int(d/(24*time.Hour)), int(d/time.Hour)
+	switch {
+	case days > 1:
+		return fmt.Sprintf("%d days", days)
+	case days == 1:
+		return "1 day"
+	case hours == 1:
+		return "1 hour"
+	default:
+		return fmt.Sprintf("%d hours", hours)
+	}
+}
+
+// reminders reminds the current players of the running games of turns that have waited longer than the threshold.
+// It is requested periodically by cron, and may also be requested by admins.
+func reminders(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	if !cronOrAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only cron and admins may send reminders."})
+		return
+	}
+
+	gs, err := runningGames(ctx)
+	if err != nil {
+		log.Errorf(ctx, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to find running games"})
+		return
+	}
+
+	now := time.Now()
+	reminded := make([]int64, 0)
+	for _, g := range gs {
+		r, ok := g.reminderDue(ctx, now)
+		if !ok {
+			continue
+		}
+
+		cp, wv := g.CurrentPlayer(), g.waitingView(now)
+		data := struct {
+			Name string
+			waitingView
+		}{g.NameFor(cp), wv}
+
+		body, err := notificationBody(ctx, "got/turn_reminder_notification", reminderTemplate, data)
+		if err != nil {
+			log.Warningf(ctx, "unable to compose reminder for game %d: %v", g.ID, err)
+			continue
+		}
+
+		// A reminder sent on any channel is recorded, so that the other channels are not retried
+		// before the next reminder is due.
+		if err = notify(ctx, &Notification{
+			Event:    turnReminder,
+			User:     cp.User(),
+			Subject:  fmt.Sprintf("SlothNinja Games: Reminder of Your Turn in Guild of Thieves #%d", g.ID),
+			HTMLBody: body,
+			Text:     fmt.Sprintf("Guild of Thieves #%d, %s, has awaited your turn for %s.", wv.ID, wv.Title, wv.Waiting),
+			GameIDs:  []int64{g.ID},
+		}); err != nil {
+			log.Warningf(ctx, "unable to remind game %d: %v", g.ID, err)
+		}
+
+		if err = datastore.Put(ctx, r); err != nil {
+			log.Warningf(ctx, "unable to record reminder for game %d: %v", g.ID, err)
+			continue
+		}
+		reminded = append(reminded, g.ID)
+	}
+	c.JSON(http.StatusOK, gin.H{"version": apiVersion, "reminded": reminded})
+}
+
+// digest lists the games awaiting a user.
+type digest struct {
+	User  *user.User
+	Name  string
+	Games []waitingView
+}
+
+// notification returns the notification of the digest, with the HTML body.
+func (d *digest) notification(body string) *Notification {
+	n := &Notification{
+		Event:    gamesDigest,
+		User:     d.User,
+		Subject:  "SlothNinja Games: Guild of Thieves Games Awaiting Your Turn",
+		HTMLBody: body,
+	}
+
+	lines := []string{"Guild of Thieves games awaiting your turn:"}
+	for _, wv := range d.Games {
+		lines = append(lines, fmt.Sprintf("#%d, %s, for %s", wv.ID, wv.Title, wv.Waiting))
+		n.GameIDs = append(n.GameIDs, wv.ID)
+	}
+	n.Text = strings.Join(lines, "\n")
+	return n
+}
+
+// awaitedTurn is the turn of a game awaiting a user.
+type awaitedTurn struct {
+	User *user.User
+	Name string
+	Game waitingView
+}
+
+// digests returns the digests of the users awaited by games gs at time now, sorted by user id.
+func digests(ctx context.Context, gs []*Game, now time.Time) []*digest {
+	var ts []awaitedTurn
+	for _, g := range gs {
+		if cp := g.awaiting(ctx, now); cp != nil {
+			ts = append(ts, awaitedTurn{User: cp.User(), Name: g.NameFor(cp), Game: g.waitingView(now)})
+		}
+	}
+	return digestsOf(ts)
+}
+
+// digestsOf returns the digests listing the turns ts by user, sorted by user id.
+func digestsOf(ts []awaitedTurn) []*digest {
+	byUser := make(map[int64]*digest)
+	for _, t := range ts {
+		d, ok := byUser[t.User.ID]
+		if !ok {
+			d = &digest{User: t.User, Name: t.Name}
+			byUser[t.User.ID] = d
+		}
+		d.Games = append(d.Games, t.Game)
+	}
+
+	ds := make([]*digest, 0, len(byUser))
+	for _, d := range byUser {
+		ds = append(ds, d)
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i].User.ID < ds[j].User.ID })
+	return ds
+}
+
+// sendDigests sends each user awaited by a running game a list of the games awaiting the user.
+// It is requested daily by cron, and may also be requested by admins.
+func sendDigests(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	if !cronOrAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only cron and admins may send digests."})
+		return
+	}
+
+	gs, err := runningGames(ctx)
+	if err != nil {
+		log.Errorf(ctx, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to find running games"})
+		return
+	}
+
+	sent := 0
+	for _, d := range digests(ctx, gs, time.Now()) {
+		body, err := notificationBody(ctx, "got/digest_notification", digestTemplate, d)
+		if err != nil {
+			log.Warningf(ctx, "unable to compose digest for user %d: %v", d.User.ID, err)
+			continue
+		}
+
+		if err = notify(ctx, d.notification(body)); err != nil {
+			log.Warningf(ctx, "unable to send digest to user %d: %v", d.User.ID, err)
+			continue
+		}
+		sent++
+	}
+	c.JSON(http.StatusOK, gin.H{"version": apiVersion, "sent": sent})
+}
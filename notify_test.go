@@ -0,0 +1,181 @@
+package got
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/user"
+	"go.chromium.org/gae/service/mail"
+	"golang.org/x/net/context"
+)
+
+// fakeNotifier records the email it is asked to send.
+type fakeNotifier struct {
+	sent []*mail.Message
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, ms ...*mail.Message) error {
+	n.sent = append(n.sent, ms...)
+	return nil
+}
+
+// withNotifier replaces the notifier with n, returning a function restoring the original.
+func withNotifier(n Notifier) func() {
+	old := notifier
+	SetNotifier(n)
+	return func() { SetNotifier(old) }
+}
+
+func TestEmailSender(t *testing.T) {
+	fake := new(fakeNotifier)
+	defer withNotifier(fake)()
+
+	ctx := context.Background()
+	n := &Notification{
+		Event:    turnReminder,
+		User:     &user.User{ID: 1, Name: "Ann", Email: "ann@example.com"},
+		Subject:  "Your turn",
+		HTMLBody: "<p>Your turn</p>",
+	}
+	if err := (emailSender{}).Send(ctx, n); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*mail.Message{{
+		To:       []string{"ann@example.com"},
+		Sender:   notificationSender,
+		Subject:  "Your turn",
+		HTMLBody: "<p>Your turn</p>",
+	}}
+	if !reflect.DeepEqual(fake.sent, want) {
+		t.Errorf("sent %+v, want %+v", fake.sent, want)
+	}
+
+	// Notifications formatted elsewhere send their own email.
+	mailed := false
+	n.mail = func(context.Context) error { mailed = true; return nil }
+	if err := (emailSender{}).Send(ctx, n); err != nil {
+		t.Fatal(err)
+	}
+	if !mailed || len(fake.sent) != 1 {
+		t.Errorf("mailed %t and the notifier sent %d messages, want true and 1", mailed, len(fake.sent))
+	}
+}
+
+func TestReminderDue(t *testing.T) {
+	defer SetReminderThreshold(reminderThreshold)
+	SetReminderThreshold(48 * time.Hour)
+
+	since := day(0, 0)
+	tests := []struct {
+		name string
+		sent time.Time
+		now  time.Time
+		want bool
+	}{
+		{"before the threshold", time.Time{}, day(1, 23), false},
+		{"at the threshold", time.Time{}, day(2, 0), true},
+		{"after the threshold", time.Time{}, day(5, 0), true},
+		{"reminded before the turn began", day(-1, 0), day(2, 0), true},
+		{"reminded recently", day(2, 0), day(3, 0), false},
+		{"reminded a threshold ago", day(2, 0), day(4, 0), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &reminder{ID: 1, Sent: tt.sent}
+			if got := r.due(since, tt.now); got != tt.want {
+				t.Errorf("got due %t, want %t", got, tt.want)
+			}
+		})
+	}
+
+	SetReminderThreshold(24 * time.Hour)
+	if r := new(reminder); !r.due(since, day(1, 0)) {
+		t.Error("a reminder is not due once the turn has waited the threshold set")
+	}
+}
+
+func TestWaitingString(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0 hours"},
+		{59 * time.Minute, "0 hours"},
+		{time.Hour, "1 hour"},
+		{23*time.Hour + 59*time.Minute, "23 hours"},
+		{24 * time.Hour, "1 day"},
+		{47 * time.Hour, "1 day"},
+		{48 * time.Hour, "2 days"},
+		{100 * time.Hour, "4 days"},
+	}
+
+	for _, tt := range tests {
+		if got := waitingString(tt.d); got != tt.want {
+			t.Errorf("waitingString(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestAwaits(t *testing.T) {
+	now := day(1, 0)
+	u := &user.User{ID: 1}
+	bot := newPlayer()
+	bot.Bot = randomBot
+
+	tests := []struct {
+		name string
+		p    *Player
+		u    *user.User
+		vs   vacations
+		want bool
+	}{
+		{"player", newPlayer(), u, nil, true},
+		{"bot", bot, nil, nil, false},
+		{"no user", newPlayer(), nil, nil, false},
+		{"on vacation", newPlayer(), u, vacations{vac(day(0, 0), day(2, 0))}, false},
+		{"back from vacation", newPlayer(), u, vacations{vac(day(-2, 0), day(1, 0))}, true},
+		{"vacation to come", newPlayer(), u, vacations{vac(day(1, 1), day(3, 0))}, true},
+	}
+
+	for _, tt := range tests {
+		if got := awaits(tt.p, tt.u, tt.vs, now); got != tt.want {
+			t.Errorf("%s: got %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDigestsOf(t *testing.T) {
+	ann, bob := &user.User{ID: 2, Name: "Ann"}, &user.User{ID: 1, Name: "Bob"}
+	g1 := waitingView{ID: 10, Title: "first", Waiting: "2 days"}
+	g2 := waitingView{ID: 11, Title: "second", Waiting: "3 hours"}
+	g3 := waitingView{ID: 12, Title: "third", Waiting: "1 day"}
+
+	ds := digestsOf([]awaitedTurn{
+		{User: ann, Name: "Ann", Game: g1},
+		{User: bob, Name: "Bob", Game: g2},
+		{User: ann, Name: "Ann", Game: g3},
+	})
+
+	want := []*digest{
+		{User: bob, Name: "Bob", Games: []waitingView{g2}},
+		{User: ann, Name: "Ann", Games: []waitingView{g1, g3}},
+	}
+	if !reflect.DeepEqual(ds, want) {
+		t.Fatalf("got digests %+v, want %+v", ds, want)
+	}
+
+	n := ds[1].notification("<p>body</p>")
+	if n.Event != gamesDigest || n.User != ann || !reflect.DeepEqual(n.GameIDs, []int64{10, 12}) {
+		t.Errorf("got notification of %s to %v for games %v", n.Event, n.User, n.GameIDs)
+	}
+	if wantText := "Guild of Thieves games awaiting your turn:\n#10, first, for 2 days\n#12, third, for 1 day"; n.Text != wantText {
+		t.Errorf("got text %q, want %q", n.Text, wantText)
+	}
+
+	if ds := digestsOf(nil); len(ds) != 0 {
+		t.Errorf("got %d digests of no turns", len(ds))
+	}
+}
@@ -1,17 +1,17 @@
 package got
 
 import (
-	"encoding/gob"
 	"html/template"
 
 	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"github.com/SlothNinja/gt/engine"
 	"golang.org/x/net/context"
 )
 
 func init() {
-	gob.Register(new(passEntry))
+	registerEntry("pass", new(passEntry))
 }
 
 func (g *Game) pass(ctx context.Context) (string, game.ActionType, error) {
@@ -22,14 +22,12 @@ func (g *Game) pass(ctx context.Context) (string, game.ActionType, error) {
 		return "got/flash_notice", game.None, err
 	}
 
-	cp := g.CurrentPlayer()
-	cp.Passed = true
-	cp.PerformedAction = true
-	g.Phase = drawCard
-
-	// Log Pass
-	e := g.newPassEntryFor(cp)
-	restful.AddNoticef(ctx, string(e.HTML(g)))
+	if err := g.apply(ctx, engine.Action{
+		Type:     engine.Pass,
+		PlayerID: g.CurrentPlayer().ID(),
+	}); err != nil {
+		return "got/flash_notice", game.None, err
+	}
 
 	return "got/pass_update", game.Cache, nil
 }
@@ -45,12 +43,11 @@ type passEntry struct {
 	*Entry
 }
 
-func (g *Game) newPassEntryFor(p *Player) (e *passEntry) {
+func (g *Game) newPassEntry(pe *engine.PassEvent) (e *passEntry) {
 	e = &passEntry{
-		Entry: g.newEntryFor(p),
+		Entry: g.newEntryFromEvent(pe),
 	}
-	p.Log = append(p.Log, e)
-	g.Log = append(g.Log, e)
+	g.addEntry(pe.PlayerID, e)
 	return
 }
 
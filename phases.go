@@ -1,22 +1,25 @@
 package got
 
-import "bitbucket.org/SlothNinja/slothninja-games/sn/game"
+import (
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"github.com/SlothNinja/gt/engine"
+)
 
 const (
-	noPhase game.Phase = iota
-	setup
-	startGame
-	placeThieves
-	playCard
-	selectThief
-	moveThief
-	claimItem
-	drawCard
-	finalClaim
-	announceWinners
-	gameOver
-	endGame
-	awaitPlayerInput
+	noPhase          = game.Phase(engine.NoPhase)
+	setup            = game.Phase(engine.PhaseSetup)
+	startGame        = game.Phase(engine.PhaseStartGame)
+	placeThieves     = game.Phase(engine.PhasePlaceThieves)
+	playCard         = game.Phase(engine.PhasePlayCard)
+	selectThief      = game.Phase(engine.PhaseSelectThief)
+	moveThief        = game.Phase(engine.PhaseMoveThief)
+	claimItem        = game.Phase(engine.PhaseClaimItem)
+	drawCard         = game.Phase(engine.PhaseDrawCard)
+	finalClaim       = game.Phase(engine.PhaseFinalClaim)
+	announceWinners  = game.Phase(engine.PhaseAnnounceWinners)
+	gameOver         = game.Phase(engine.PhaseGameOver)
+	endGame          = game.Phase(engine.PhaseEndGame)
+	awaitPlayerInput = game.Phase(engine.PhaseAwaitPlayerInput)
 )
 
 var phaseNames = game.PhaseNameMap{
@@ -1,17 +1,17 @@
 package got
 
 import (
-	"encoding/gob"
 	"html/template"
 
 	"bitbucket.org/SlothNinja/slothninja-games/sn"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"github.com/SlothNinja/gt/engine"
 	"golang.org/x/net/context"
 )
 
 func init() {
-	gob.Register(new(placeThiefEntry))
+	registerEntry("place-thief", new(placeThiefEntry))
 }
 
 func (g *Game) placeThieves(ctx context.Context) error {
@@ -30,14 +30,15 @@ func (g *Game) placeThief(ctx context.Context) (tmpl string, err error) {
 		tmpl = "got/flash_notice"
 		return
 	}
-	cp := g.CurrentPlayer()
-	cp.PerformedAction = true
-	cp.Score += g.SelectedArea().Card.Value()
-	g.SelectedArea().Thief = cp.ID()
 
-	// Log placement
-	e := g.newPlaceThiefEntryFor(cp)
-	restful.AddNoticef(ctx, string(e.HTML(g)))
+	if err = g.apply(ctx, engine.Action{
+		Type:     engine.PlaceThief,
+		PlayerID: g.CurrentPlayer().ID(),
+		Position: g.SelectedArea().Position(),
+	}); err != nil {
+		tmpl = "got/flash_notice"
+		return
+	}
 	return "got/place_thief_update", nil
 }
 
@@ -46,18 +47,10 @@ func (g *Game) validatePlaceThief(ctx context.Context) error {
 		return err
 	}
 
-	//g.debugf("Place Thief Area: %#v", g.SelectedArea)
-
-	switch area := g.SelectedArea(); {
-	case area == nil:
+	if g.SelectedArea() == nil {
 		return sn.NewVError("You must select an area.")
-	case area.Card == nil:
-		return sn.NewVError("You must select an area with a card.")
-	case area.Thief != noPID:
-		return sn.NewVError("You must select an area without a thief.")
-	default:
-		return nil
 	}
+	return nil
 }
 
 type placeThiefEntry struct {
@@ -65,14 +58,12 @@ type placeThiefEntry struct {
 	Area Area
 }
 
-func (g *Game) newPlaceThiefEntryFor(p *Player) (e *placeThiefEntry) {
-	area := g.SelectedArea()
+func (g *Game) newPlaceThiefEntry(pe *engine.PlaceThiefEvent) (e *placeThiefEntry) {
 	e = &placeThiefEntry{
-		Entry: g.newEntryFor(p),
-		Area:  *area,
+		Entry: g.newEntryFromEvent(pe),
+		Area:  pe.Area,
 	}
-	p.Log = append(p.Log, e)
-	g.Log = append(g.Log, e)
+	g.addEntry(pe.PlayerID, e)
 	return
 }
 
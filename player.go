@@ -4,6 +4,7 @@ import (
 	"encoding/gob"
 	"html/template"
 	"sort"
+	"time"
 
 	"bitbucket.org/SlothNinja/slothninja-games/sn/color"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/contest"
@@ -12,6 +13,7 @@ import (
 	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/schema"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/user"
+	"github.com/SlothNinja/gt/engine"
 	"go.chromium.org/gae/service/datastore"
 	"golang.org/x/net/context"
 )
@@ -27,20 +29,15 @@ type Player struct {
 	Hand        Cards
 	DrawPile    Cards
 	DiscardPile Cards
+	// Bot names the strategy of a computer controlled player.  It is empty for human players.
+	Bot string
+	// Bank is the time remaining to the player under the bank time control.
+	Bank time.Duration
 }
 
 // Players is a slice of players of the game.
 type Players []*Player
 
-func (ps Players) allPassed() bool {
-	for _, p := range ps {
-		if !p.Passed {
-			return false
-		}
-	}
-	return true
-}
-
 // Len is part of the sort.Interface interface
 func (ps Players) Len() int { return len(ps) }
 
@@ -69,7 +66,7 @@ func (p *Player) compareByScore(p2 *Player) (result game.Comparison) {
 }
 
 func (p *Player) compareByLamps(p2 *Player) game.Comparison {
-	switch c0, c1 := lampCount(p.Hand...), lampCount(p2.Hand...); {
+	switch c0, c1 := engine.LampCount(p.Hand...), engine.LampCount(p2.Hand...); {
 	case c0 < c1:
 		return game.LessThan
 	case c0 > c1:
@@ -78,30 +75,8 @@ func (p *Player) compareByLamps(p2 *Player) game.Comparison {
 	return game.EqualTo
 }
 
-// CountFor provides the number of faceUp and faceDown cards a player has.
-func (cs Cards) CountFor(t cType) (faceUp, faceDown int) {
-	for _, c := range cs {
-		switch {
-		case c.Type == t && c.FaceUp:
-			faceUp++
-		case c.Type == t && !c.FaceUp:
-			faceDown++
-		}
-	}
-	return
-}
-
-func lampCount(cs ...*Card) (count int) {
-	for _, c := range cs {
-		if c.Type == lamp || c.Type == sLamp {
-			count++
-		}
-	}
-	return count
-}
-
 func (p *Player) compareByCamels(p2 *Player) game.Comparison {
-	switch c0, c1 := camelCount(p.Hand...), camelCount(p2.Hand...); {
+	switch c0, c1 := engine.CamelCount(p.Hand...), engine.CamelCount(p2.Hand...); {
 	case c0 < c1:
 		return game.LessThan
 	case c0 > c1:
@@ -110,15 +85,6 @@ func (p *Player) compareByCamels(p2 *Player) game.Comparison {
 	return game.EqualTo
 }
 
-func camelCount(cs ...*Card) (count int) {
-	for _, c := range cs {
-		if c.Type == camel || c.Type == sCamel {
-			count++
-		}
-	}
-	return count
-}
-
 func (p *Player) compareByCards(p2 *Player) game.Comparison {
 	switch c0, c1 := len(p.Hand), len(p2.Hand); {
 	case c0 < c1:
@@ -145,6 +111,9 @@ func (p *Player) compareByCards(p2 *Player) game.Comparison {
 func (g *Game) determinePlaces(ctx context.Context) contest.Places {
 	log.Debugf(ctx, "Entering")
 	defer log.Debugf(ctx, "Exiting")
+	if g.isTeamGame() {
+		return g.determineTeamPlaces(ctx)
+	}
 	// sort players by score
 	players := g.Players()
 	sort.Sort(Reverse{ByScore{players}})
@@ -210,7 +179,7 @@ func (p *Player) init(gr game.Gamer) {
 
 func newPlayer() *Player {
 	p := &Player{
-		Hand:        newStartHand(),
+		Hand:        engine.NewStartHand(),
 		DrawPile:    make(Cards, 0),
 		DiscardPile: make(Cards, 0),
 	}
@@ -228,7 +197,7 @@ func createPlayer(g *Game, u *user.User) *Player {
 	p.SetID(int(len(g.Players())))
 	p.SetGame(g)
 
-	colorMap := g.DefaultColorMap()
+	colorMap := g.colorMap()
 	p.SetColorMap(make(color.Colors, g.NumPlayers))
 
 	for i := 0; i < g.NumPlayers; i++ {
@@ -243,6 +212,32 @@ func createPlayer(g *Game, u *user.User) *Player {
 	return p
 }
 
+// extraColors extend the default colors of the players for games having more players than the default colors.
+var extraColors = color.Colors{color.Red, color.Yellow, color.Purple, color.Black, color.Brown, color.Green, color.Blue, color.Orange}
+
+// colorMap returns a color for each player: the default colors, extended by colors not already in use.
+func (g *Game) colorMap() color.Colors {
+	cm := append(color.Colors(nil), g.DefaultColorMap()...)
+	for _, c := range extraColors {
+		if len(cm) >= g.NumPlayers {
+			break
+		}
+		if !hasColor(cm, c) {
+			cm = append(cm, c)
+		}
+	}
+	return cm
+}
+
+func hasColor(cs color.Colors, c color.Color) bool {
+	for _, c2 := range cs {
+		if c2 == c {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *Player) beginningOfTurnReset() {
 	p.clearActions()
 }
@@ -260,32 +255,13 @@ func (p *Player) clearActions() {
 }
 
 // CanClick indicates whether a particular player can select an area.
-func (g *Game) CanClick(ctx context.Context, p *Player, a *Area) (b bool) {
-	cp := g.CurrentPlayer()
+func (g *Game) CanClick(ctx context.Context, p *Player, a *Area) bool {
 	switch g.Phase {
-	case placeThieves:
-		b = g.CUserIsCPlayerOrAdmin(ctx) && !cp.PerformedAction && a.Thief == noPID
-	case selectThief:
-		b = g.CUserIsCPlayerOrAdmin(ctx) && !cp.PerformedAction && a.Thief == cp.ID()
-	case moveThief:
-		switch {
-		case g.PlayedCard.Type == lamp || g.PlayedCard.Type == sLamp:
-			b = g.CUserIsCPlayerOrAdmin(ctx) && !cp.PerformedAction && g.isLampArea(a)
-		case g.PlayedCard.Type == camel || g.PlayedCard.Type == sCamel:
-			b = g.CUserIsCPlayerOrAdmin(ctx) && !cp.PerformedAction && g.isCamelArea(a)
-		case g.PlayedCard.Type == sword:
-			b = g.CUserIsCPlayerOrAdmin(ctx) && !cp.PerformedAction && g.isSwordArea(a)
-		case g.PlayedCard.Type == carpet:
-			b = g.CUserIsCPlayerOrAdmin(ctx) && !cp.PerformedAction && g.isCarpetArea(a)
-		case g.PlayedCard.Type == turban && g.Stepped == 0:
-			b = g.CUserIsCPlayerOrAdmin(ctx) && !cp.PerformedAction && g.isTurban0Area(a)
-		case g.PlayedCard.Type == turban && g.Stepped == 1:
-			b = g.CUserIsCPlayerOrAdmin(ctx) && !cp.PerformedAction && g.isTurban1Area(a)
-		case g.PlayedCard.Type == coins:
-			b = g.CUserIsCPlayerOrAdmin(ctx) && !cp.PerformedAction && g.isCoinsArea(a)
-		}
+	case placeThieves, selectThief, moveThief:
+		return g.CUserIsCPlayerOrAdmin(ctx) && g.clickAreas().Include(a)
+	default:
+		return false
 	}
-	return
 }
 
 // CanPlaceThief indicates whether a particular player can place a thief.
@@ -317,16 +293,6 @@ func (g *Game) CanMoveThief(ctx context.Context, p *Player) bool {
 		g.SelectedThiefArea() != nil
 }
 
-func (g *Game) endOfTurnUpdateFor(p *Player) {
-	if g.PlayedCard != nil {
-		g.Jewels = *(g.PlayedCard)
-	}
-
-	for _, card := range p.Hand {
-		card.FaceUp = true
-	}
-}
-
 var playerValues = sslice{"Player.Passed", "Player.PerformedAction", "Player.Score"}
 
 func (g *Game) adminPlayer(c context.Context) (string, game.ActionType, error) {
@@ -359,7 +325,7 @@ func (g *Game) adminUpdatePlayer(ctx context.Context, ss sslice) error {
 
 func (g *Game) handMapFor(p *Player) (hm map[cType]int, count int) {
 	hm = make(map[cType]int)
-	for _, t := range g.cardTypes() {
+	for _, t := range engine.CardTypes() {
 		faceUp, faceDown := p.Hand.CountFor(t)
 		if faceUp > 0 {
 			hm[t] = faceUp
@@ -385,7 +351,7 @@ func (g *Game) PlayCardDisplayFor(p *Player) (s template.HTML) {
 			name := t.IDString()
 			s += restful.HTML("<div class=%q>", pos)
 			s += restful.HTML("<div id='card-%s' data-tip=%q class='clickable card %s'></div>",
-				name, t.toolTip(), name)
+				name, t.ToolTip(), name)
 			s += restful.HTML("<div class='center'>%d</div></div>", count)
 
 			if cardTypes%2 == 0 {
@@ -408,7 +374,7 @@ func (g *Game) DisplayHandFor(ctx context.Context, p *Player) (s template.HTML)
 			if count > 0 {
 				name := t.IDString()
 				s += restful.HTML("<div class='pull-left'>")
-				s += restful.HTML("<div data-tip=%q class='card %s'></div>", t.toolTip(), name)
+				s += restful.HTML("<div data-tip=%q class='card %s'></div>", t.ToolTip(), name)
 				s += restful.HTML("<div class='center'>%d</div></div>", count)
 			}
 		}
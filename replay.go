@@ -0,0 +1,298 @@
+package got
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/codec"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/color"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/mlog"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/user"
+	"github.com/SlothNinja/gt/engine"
+	"github.com/gin-gonic/gin"
+	"go.chromium.org/gae/service/info"
+	"golang.org/x/net/context"
+)
+
+// errNoReplay is returned for games whose log predates recording of the game's seed.
+var errNoReplay = errors.New("game log does not support replay")
+
+// replay tracks the rules engine state while stepping through the game log.
+type replay struct {
+	s       *engine.State
+	pending []engine.Event
+	// turns records the state at the start of each turn, and the state once the last turn is finished.
+	turns []*engine.State
+}
+
+// replayer is implemented by log entries that affect the rules engine state.
+type replayer interface {
+	replayOn(r *replay) error
+}
+
+// apply applies the action recorded by a log entry, first finishing the turn of
+// the current player if that player has already performed an action.
+func (r *replay) apply(a engine.Action) (err error) {
+	if r.s == nil {
+		return errNoReplay
+	}
+
+	if cp := r.s.CurrentPlayer(); a.Type != engine.FinishTurn && cp != nil && cp.PerformedAction {
+		if err = r.finish(); err != nil {
+			return
+		}
+	}
+
+	var es []engine.Event
+	if r.s, es, err = engine.Apply(r.s, a); err != nil {
+		return
+	}
+
+	if a.Type == engine.FinishTurn {
+		r.turns = append(r.turns, r.s)
+	}
+
+	// The first event corresponds to the entry recording the action.
+	// Any others are recorded by the entries that follow it.
+	if len(es) > 0 {
+		es = es[1:]
+	}
+	r.pending = es
+	return
+}
+
+// finish finishes the turn of the current player, if that player has performed an action.
+func (r *replay) finish() error {
+	if r.s == nil {
+		return errNoReplay
+	}
+
+	if cp := r.s.CurrentPlayer(); cp != nil && cp.PerformedAction {
+		return r.apply(engine.Action{Type: engine.FinishTurn, PlayerID: cp.ID})
+	}
+	return nil
+}
+
+// next removes and returns the next event awaiting a log entry.
+func (r *replay) next() (e engine.Event, err error) {
+	if len(r.pending) == 0 {
+		return nil, errors.New("log records more than replay produced")
+	}
+	e, r.pending = r.pending[0], r.pending[1:]
+	return
+}
+
+func (e *startEntry) replayOn(r *replay) error {
+	if len(e.PlayerIDs) == 0 {
+		return errNoReplay
+	}
+	r.s = engine.NewWithRuleset(e.PlayerIDs, e.TwoThiefVariant, e.Ruleset, e.Seed)
+	r.s.Teams = e.Teams
+	r.turns = append(r.turns, r.s)
+	return nil
+}
+
+func (e *placeThiefEntry) replayOn(r *replay) error {
+	return r.apply(engine.Action{
+		Type:     engine.PlaceThief,
+		PlayerID: e.PlayerID,
+		Position: e.Area.Position(),
+	})
+}
+
+func (e *playCardEntry) replayOn(r *replay) error {
+	return r.apply(engine.Action{
+		Type:     engine.PlayCard,
+		PlayerID: e.PlayerID,
+		Card:     e.Type,
+	})
+}
+
+func (e *moveThiefEntry) replayOn(r *replay) (err error) {
+	if r.s != nil && r.s.Phase == engine.PhaseSelectThief {
+		if err = r.apply(engine.Action{
+			Type:     engine.SelectThief,
+			PlayerID: e.PlayerID,
+			Position: e.From.Position(),
+		}); err != nil {
+			return
+		}
+	}
+	return r.apply(engine.Action{
+		Type:     engine.MoveThief,
+		PlayerID: e.PlayerID,
+		Position: e.To.Position(),
+	})
+}
+
+func (e *claimItemEntry) replayOn(r *replay) error {
+	ev, err := r.next()
+	if err != nil {
+		return err
+	}
+
+	switch ce, ok := ev.(*engine.ClaimItemEvent); {
+	case !ok || ce.Area.Position() != e.Area.Position():
+		return errors.New("replay claimed different item than log")
+	default:
+		return nil
+	}
+}
+
+func (e *drawCardEntry) replayOn(r *replay) error {
+	ev, err := r.next()
+	if err != nil {
+		return err
+	}
+
+	switch de, ok := ev.(*engine.DrawCardEvent); {
+	case !ok || de.Card.Type != e.Card.Type || de.RandPos != e.RandPos:
+		return errors.New("replay drew different card than log")
+	default:
+		return nil
+	}
+}
+
+func (e *passEntry) replayOn(r *replay) error {
+	return r.apply(engine.Action{Type: engine.Pass, PlayerID: e.PlayerID})
+}
+
+func (e *endGameEntry) replayOn(r *replay) error {
+	return r.finish()
+}
+
+// Replay reconstructs the rules engine state immediately following entry n of the game log.
+// Entries preceding the start of the game share the state at the start of the game.
+func (g *Game) Replay(n int) (*engine.State, error) {
+	if n < 0 || n >= len(g.Log) {
+		return nil, fmt.Errorf("no log entry %d", n)
+	}
+
+	r, err := g.replay(n)
+	if err != nil {
+		return nil, err
+	}
+	return r.s, nil
+}
+
+// replay replays the game log through entry n, continuing past it to the start of the game if need be.
+func (g *Game) replay(n int) (*replay, error) {
+	r := new(replay)
+	for i, e := range g.Log {
+		if i > n && r.s != nil {
+			break
+		}
+
+		if rep, ok := e.(replayer); ok {
+			if err := rep.replayOn(r); err != nil {
+				return nil, fmt.Errorf("unable to replay entry %d: %v", i, err)
+			}
+		}
+	}
+
+	if r.s == nil {
+		return nil, errNoReplay
+	}
+	return r, nil
+}
+
+// positionAt returns a copy of the game showing the position immediately following entry n of the game log.
+func (g *Game) positionAt(ctx context.Context, n int) (*Game, error) {
+	pg := New(ctx)
+	pg.ID = g.ID
+	if err := pg.setPosition(g, n); err != nil {
+		return nil, err
+	}
+	return pg, nil
+}
+
+// setPosition makes the game a copy of game from showing the position immediately following entry n of its log.
+func (g *Game) setPosition(from *Game, n int) error {
+	s, err := from.Replay(n)
+	if err != nil {
+		return err
+	}
+
+	v, err := codec.Encode(from)
+	if err != nil {
+		return err
+	}
+
+	if err = codec.Decode(g, v); err != nil {
+		return err
+	}
+
+	if err = g.afterCache(); err != nil {
+		return err
+	}
+
+	g.setEngineState(s)
+	g.Log = g.Log[:n+1]
+	return nil
+}
+
+func positionPath(prefix, sid string, n int) string {
+	return fmt.Sprintf("/%s/game/show/%s/position/%d", prefix, sid, n)
+}
+
+func position(prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := restful.ContextFrom(c)
+		log.Debugf(ctx, "Entering")
+		defer log.Debugf(ctx, "Exiting")
+
+		g := gameFrom(ctx)
+		if g == nil {
+			log.Errorf(ctx, "game not found")
+			c.Redirect(http.StatusSeeOther, homePath)
+			return
+		}
+
+		if g.Phase != gameOver && !user.IsAdmin(ctx) {
+			restful.AddErrorf(ctx, "Positions may only be viewed once the game is over.")
+			c.Redirect(http.StatusSeeOther, showPath(prefix, c.Param("hid")))
+			return
+		}
+
+		n, err := strconv.Atoi(c.Param("n"))
+		if err != nil {
+			restful.AddErrorf(ctx, "Invalid position: %q", c.Param("n"))
+			c.Redirect(http.StatusSeeOther, showPath(prefix, c.Param("hid")))
+			return
+		}
+
+		pg, err := g.positionAt(ctx, n)
+		if err != nil {
+			log.Warningf(ctx, "g.positionAt error: %v", err)
+			restful.AddErrorf(ctx, "Unable to show position %d: %v", n, err)
+			c.Redirect(http.StatusSeeOther, showPath(prefix, c.Param("hid")))
+			return
+		}
+
+		cu := user.CurrentFrom(ctx)
+		d := gin.H{
+			"Context":    ctx,
+			"VersionID":  info.VersionID(ctx),
+			"CUser":      cu,
+			"Game":       pg,
+			"IsAdmin":    user.IsAdmin(ctx),
+			"Admin":      game.AdminFrom(ctx),
+			"MessageLog": mlog.From(ctx),
+			"ColorMap":   color.MapFrom(ctx),
+			"Position":   n,
+			"Positions":  len(g.Log),
+		}
+		if n > 0 {
+			d["PreviousPath"] = positionPath(prefix, c.Param("hid"), n-1)
+		}
+		if n < len(g.Log)-1 {
+			d["NextPath"] = positionPath(prefix, c.Param("hid"), n+1)
+		}
+		c.HTML(http.StatusOK, prefix+"/show", d)
+	}
+}
@@ -0,0 +1,125 @@
+package got
+
+import (
+	"reflect"
+	"testing"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"github.com/SlothNinja/gt/engine"
+)
+
+// playedGame is a game played to its end by random moves, together with the states of the rules engine
+// once each turn is finished, keyed by the last entry of the log preceding them.
+type playedGame struct {
+	*Game
+	turns map[int]*engine.State
+	final *engine.State
+}
+
+func playGame(t *testing.T, numPlayers int, seed int64) *playedGame {
+	t.Helper()
+
+	g := &Game{Header: &game.Header{ID: 1, Title: "replay", NumPlayers: numPlayers, Status: game.Running}, State: newState()}
+	ids := make([]int, numPlayers)
+	for i := range ids {
+		p := newPlayer()
+		p.SetID(i)
+		p.Bot = randomBot
+		g.Playerers = append(g.Playerers, p)
+		ids[i] = i
+	}
+	s := engine.New(ids, false, seed)
+	g.setEngineState(s)
+	g.newStartEntry()
+
+	// Play through the rules engine, logging events as the game does.
+	pg := &playedGame{Game: g, turns: make(map[int]*engine.State)}
+	r := engine.NewRand(seed + 1)
+	for s.Phase != engine.PhaseGameOver {
+		m := engine.RandomStrategy{}.Choose(s, s.CurrentPlayerID, engine.LegalActions(s, s.CurrentPlayerID), &r)
+		for _, a := range m.Actions {
+			var (
+				es  []engine.Event
+				err error
+			)
+			if s, es, err = engine.Apply(s, a); err != nil {
+				t.Fatalf("applying %+v: %v", a, err)
+			}
+			g.setEngineState(s)
+			for _, e := range es {
+				g.logEvent(e)
+			}
+			if a.Type == engine.FinishTurn && s.Phase != engine.PhaseGameOver {
+				pg.turns[len(g.Log)-1] = s
+			}
+		}
+	}
+	pg.final = s
+	g.newEndGameEntry()
+	return pg
+}
+
+// sameState reports how the replayed state differs from the state played, if it does.
+func sameState(t *testing.T, what string, got, want *engine.State) {
+	t.Helper()
+
+	if got.Phase != want.Phase || got.Turn != want.Turn || got.CurrentPlayerID != want.CurrentPlayerID {
+		t.Errorf("%s: got phase %v of turn %d for player %d, want phase %v of turn %d for player %d", what,
+			got.Phase, got.Turn, got.CurrentPlayerID, want.Phase, want.Turn, want.CurrentPlayerID)
+	}
+	if !reflect.DeepEqual(got.Grid, want.Grid) {
+		t.Errorf("%s: the grids differ", what)
+	}
+	if !reflect.DeepEqual(got.Rand, want.Rand) {
+		t.Errorf("%s: got random source %+v, want %+v", what, got.Rand, want.Rand)
+	}
+	for i, p := range want.Players {
+		if !reflect.DeepEqual(got.Players[i], p) {
+			t.Errorf("%s: got player %+v, want %+v", what, got.Players[i], p)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	for _, numPlayers := range []int{2, 3, 4} {
+		pg := playGame(t, numPlayers, int64(numPlayers))
+		if len(pg.turns) == 0 {
+			t.Fatalf("%d players: no turn was finished", numPlayers)
+		}
+
+		// The log omits finishing turns.  Replay finishes each once the next entry belongs to another player,
+		// and finishes the last at the end of the game.
+		r, err := pg.replay(len(pg.Log) - 1)
+		if err != nil {
+			t.Fatalf("%d players: %v", numPlayers, err)
+		}
+		sameState(t, "end of game", r.s, pg.final)
+
+		for n, want := range pg.turns {
+			s, err := pg.Replay(n)
+			if err != nil {
+				t.Fatalf("%d players: replaying entry %d: %v", numPlayers, n, err)
+			}
+
+			p := &Game{Header: new(game.Header), State: newState()}
+			if err := p.setPosition(pg.Game, n); err != nil {
+				t.Fatalf("%d players: showing the position of entry %d: %v", numPlayers, n, err)
+			}
+			switch {
+			case len(p.Log) != n+1:
+				t.Errorf("%d players: the position of entry %d shows %d entries", numPlayers, n, len(p.Log))
+			case p.Phase != game.Phase(s.Phase) || p.Turn != s.Turn:
+				t.Errorf("%d players: the position of entry %d shows phase %v of turn %d, want phase %v of turn %d",
+					numPlayers, n, p.Phase, p.Turn, game.Phase(s.Phase), s.Turn)
+			case !reflect.DeepEqual(p.Grid, s.Grid):
+				t.Errorf("%d players: the position of entry %d shows a different grid", numPlayers, n)
+			}
+
+			// Replay stops after the entry, before finishing the turn.
+			if s, _, err = engine.Apply(s, engine.Action{Type: engine.FinishTurn, PlayerID: s.CurrentPlayerID}); err != nil {
+				t.Fatalf("%d players: finishing the turn of entry %d: %v", numPlayers, n, err)
+			}
+			sameState(t, "replay", s, want)
+		}
+	}
+}
@@ -25,6 +25,9 @@ func addRoutes(prefix string, engine *gin.Engine) {
 	)
 
 	// Show
+	// The JSON representation of the game, as seen by the current user, is requested as /game/show/:hid.json.
+	// It cannot be served at /game/:hid.json, as the router does not allow a parameter beside the static
+	// paths following /game/, such as /game/new.
 	g1.GET("/game/show/:hid",
 		//game.FetchHeader(GamesRoot),
 		fetch,
@@ -33,6 +36,88 @@ func addRoutes(prefix string, engine *gin.Engine) {
 		show(prefix),
 	)
 
+	// Position
+	g1.GET("/game/show/:hid/position/:n",
+		fetch,
+		mlog.Get,
+		game.SetAdmin(false),
+		position(prefix),
+	)
+
+	// Hint
+	g1.GET("/game/hint/:hid",
+		user.RequireCurrentUser(),
+		fetch,
+		hint,
+	)
+
+	// Author Scenario
+	g1.GET("/game/admin/:hid/scenario",
+		user.RequireCurrentUser(),
+		fetch,
+		authorScenario,
+	)
+
+	// Vacations
+	g1.GET("/vacations",
+		user.RequireCurrentUser(),
+		showVacations,
+	)
+
+	g1.POST("/vacations",
+		user.RequireCurrentUser(),
+		addVacation,
+	)
+
+	g1.DELETE("/vacations/:vid",
+		user.RequireCurrentUser(),
+		deleteVacation,
+	)
+
+	// Webhooks
+	g1.GET("/webhooks",
+		user.RequireCurrentUser(),
+		showWebhooks,
+	)
+
+	g1.POST("/webhooks",
+		user.RequireCurrentUser(),
+		addWebhook,
+	)
+
+	g1.DELETE("/webhooks/:wid",
+		user.RequireCurrentUser(),
+		deleteWebhook,
+	)
+
+	g1.GET("/admin/webhooks/deliveries",
+		webhookDeliveries,
+	)
+
+	// Notification Preferences
+	g1.GET("/notifications",
+		user.RequireCurrentUser(),
+		showNotificationPrefs,
+	)
+
+	g1.POST("/notifications",
+		user.RequireCurrentUser(),
+		updateNotificationPrefs,
+	)
+
+	// Best Scores
+	g1.GET("/scenarios/best",
+		user.RequireCurrentUser(),
+		bestScores,
+	)
+
+	// Live Updates
+	g1.GET("/game/live/:hid",
+		user.RequireCurrentUser(),
+		fetch,
+		live,
+	)
+
 	// Admin
 	g1.GET("/game/admin/:hid",
 		//game.FetchHeader(GamesRoot),
@@ -45,24 +130,26 @@ func addRoutes(prefix string, engine *gin.Engine) {
 	// Undo
 	g1.POST("/game/undo/:hid",
 		//game.FetchHeader(GamesRoot),
-		//UndoUpdate(),
+		user.RequireCurrentUser(),
 		fetch,
 		undo(prefix),
 	)
 
-	//	// Redo
-	//	g1.POST("/game/redo/:hid",
-	//		//game.FetchHeader(GamesRoot),
-	//		RedoUpdate(),
-	//		Redo(prefix),
-	//	)
-	//
-	//	// Reset
-	//	g1.POST("/game/reset/:hid",
-	//		//game.FetchHeader(GamesRoot),
-	//		ResetUpdate(),
-	//		Reset(prefix),
-	//	)
+	// Redo
+	g1.POST("/game/redo/:hid",
+		//game.FetchHeader(GamesRoot),
+		user.RequireCurrentUser(),
+		fetch,
+		redo(prefix),
+	)
+
+	// Reset
+	g1.POST("/game/reset/:hid",
+		//game.FetchHeader(GamesRoot),
+		user.RequireCurrentUser(),
+		fetch,
+		reset(prefix),
+	)
 
 	// Finish
 	g1.POST("/game/finish/:hid",
@@ -117,6 +204,14 @@ func addRoutes(prefix string, engine *gin.Engine) {
 		update(prefix),
 	)
 
+	// JSON Command API
+	g1.POST("/api/v1/game/:hid",
+		user.RequireCurrentUser(),
+		fetch,
+		stats.Fetch(user.CurrentFrom),
+		commandAction,
+	)
+
 	// Index
 	g1.GET("/games/:status",
 		gType.SetTypes(),
@@ -128,6 +223,31 @@ func addRoutes(prefix string, engine *gin.Engine) {
 		index(prefix),
 	)
 
+	// Take Turns of Bots
+	g1.POST("/tasks/bots/:hid",
+		botTurns,
+	)
+
+	// Time Out Turns
+	g1.GET("/cron/timeouts",
+		timeouts,
+	)
+
+	// Remind Players of Waiting Turns
+	g1.GET("/cron/reminders",
+		reminders,
+	)
+
+	// Daily Digest of Games Awaiting Players
+	g1.GET("/cron/digest",
+		sendDigests,
+	)
+
+	// Send Pending Webhook Deliveries
+	g1.GET("/cron/webhooks/retry",
+		retryWebhooks,
+	)
+
 	// JSON Data for Index
 	g1.POST("games/:status/json",
 		gType.SetTypes(),
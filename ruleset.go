@@ -0,0 +1,123 @@
+package got
+
+import (
+	"fmt"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"github.com/SlothNinja/gt/engine"
+	"golang.org/x/net/context"
+)
+
+// noGuardsRuleset names a house ruleset dealing the grid from a deck without guards.
+const noGuardsRuleset = "no-guards"
+
+// rulesets maps the names of the rulesets selectable when creating a game to the rulesets.
+var rulesets = map[string]*engine.Ruleset{
+	engine.StandardRuleset: engine.Standard(),
+	noGuardsRuleset: {
+		Name: noGuardsRuleset,
+		Deck: map[engine.CType]int{
+			engine.Lamp:   8,
+			engine.Camel:  8,
+			engine.Sword:  8,
+			engine.Carpet: 8,
+			engine.Coins:  8,
+			engine.Turban: 8,
+			engine.Jewels: 8,
+		},
+		Values:    engine.Standard().Values,
+		StartHand: engine.Standard().StartHand,
+	},
+}
+
+// layouts maps the names of the board layouts selectable when creating a game to the layouts.
+var layouts = map[string]*engine.Layout{
+	"oasis": {
+		Name:    "oasis",
+		Rows:    7,
+		Columns: 8,
+		Blocked: []engine.Position{{Row: 3, Column: 3}, {Row: 3, Column: 4}},
+	},
+	"wide": {
+		Name:    "wide",
+		Rows:    6,
+		Columns: 10,
+	},
+}
+
+// RegisterLayout makes the board layout selectable, by its name, when creating a game.
+// It must be called before serving requests.
+func RegisterLayout(l *engine.Layout) error {
+	if l.Name == "" {
+		return fmt.Errorf("a layout must have a name")
+	}
+	if _, ok := layouts[l.Name]; ok {
+		return fmt.Errorf("layout %q is already registered", l.Name)
+	}
+	layouts[l.Name] = l
+	return nil
+}
+
+// RegisterRuleset makes the ruleset selectable, by its name, when creating a game.
+// It must be called before serving requests.
+func RegisterRuleset(rs *engine.Ruleset) error {
+	if rs.Name == "" {
+		return fmt.Errorf("a ruleset must have a name")
+	}
+	if _, ok := rulesets[rs.Name]; ok {
+		return fmt.Errorf("ruleset %q is already registered", rs.Name)
+	}
+	rulesets[rs.Name] = rs
+	return nil
+}
+
+// ruleset returns the ruleset of the game.
+func (g *Game) ruleset() *engine.Ruleset {
+	if g.Ruleset == nil {
+		return engine.Standard()
+	}
+	return g.Ruleset
+}
+
+// CardValue returns the point value of the card under the ruleset of the game.
+func (g *Game) CardValue(c *Card) int {
+	return g.ruleset().Value(c.Type)
+}
+
+// rulesetFromForm sets the ruleset of the game to the ruleset and board layout named by the form.
+// The standard ruleset with the default layout is left unset, so that games played under it save no ruleset.
+func (g *Game) rulesetFromForm(ctx context.Context) error {
+	c := restful.GinFrom(ctx)
+	g.Ruleset = nil
+
+	rs := engine.Standard()
+	if name := c.PostForm("ruleset"); name != "" && name != engine.StandardRuleset {
+		var ok bool
+		if rs, ok = rulesets[name]; !ok {
+			return fmt.Errorf("unknown ruleset %q", name)
+		}
+		g.Ruleset = rs
+	}
+
+	if name := c.PostForm("layout"); name != "" {
+		l, ok := layouts[name]
+		if !ok {
+			return fmt.Errorf("unknown layout %q", name)
+		}
+		withLayout := *rs
+		withLayout.Layout = l
+		g.Ruleset = &withLayout
+	}
+	return nil
+}
+
+func (g *Game) validateNumPlayers() error {
+	if g.NumPlayers < engine.MinPlayers || g.NumPlayers > engine.MaxPlayers {
+		return fmt.Errorf("a game must have from %d to %d players, but has %d", engine.MinPlayers, engine.MaxPlayers, g.NumPlayers)
+	}
+	return nil
+}
+
+func (g *Game) validateRuleset() error {
+	return g.ruleset().Validate(g.NumPlayers, g.TwoThiefVariant)
+}
@@ -0,0 +1,297 @@
+package got
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/codec"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
+	"github.com/SlothNinja/gt/engine"
+	"golang.org/x/net/context"
+)
+
+// Saved state encodings.
+const (
+	GobEncoding  = "gob"
+	JSONEncoding = "json"
+)
+
+// savedStateVersion is the version of the saved state format written by encode.
+// Saved state predating the envelope is version 0.
+const savedStateVersion = 1
+
+// savedStateMagic begins each saved state envelope.
+// A gob stream never begins with a zero byte, so it distinguishes enveloped state from version 0.
+const savedStateMagic = "\x00GOT"
+
+var stateEncoding = GobEncoding
+
+// SetStateEncoding selects the encoding, GobEncoding or JSONEncoding, of saved state written by encode.
+// Saved state in either encoding is read regardless of the selection.
+func SetStateEncoding(enc string) error {
+	switch enc {
+	case GobEncoding, JSONEncoding:
+		stateEncoding = enc
+		return nil
+	default:
+		return fmt.Errorf("unknown saved state encoding %q", enc)
+	}
+}
+
+// A migration upgrades state decoded from saved state of one version to the following version.
+type migration func(ctx context.Context, g *Game, s *State) error
+
+// migrations maps each saved state version to the migration upgrading it to the following version.
+var migrations = map[int]migration{
+	0: migrateV0,
+}
+
+// migrateV0 gives games saved before the random stream was persisted a stream of their own.
+// The stream is seeded from the id and creation time of the game, so that each load of the saved state
+// resumes the same stream until the migrated state is saved.
+func migrateV0(ctx context.Context, g *Game, s *State) error {
+	if s.Rand == (engine.Rand{}) {
+		s.Rand = engine.NewRand(g.ID ^ g.CreatedAt.UnixNano())
+	}
+	return nil
+}
+
+// encodeState returns the saved state envelope for s.
+func encodeState(s *State) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	switch stateEncoding {
+	case JSONEncoding:
+		data, err = json.Marshal(newJSONState(s))
+	default:
+		data, err = codec.Encode(s)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	buf := bytes.NewBufferString(savedStateMagic)
+	b := make([]byte, binary.MaxVarintLen64)
+	buf.Write(b[:binary.PutUvarint(b, savedStateVersion)])
+	buf.Write(b[:binary.PutUvarint(b, uint64(len(stateEncoding)))])
+	buf.WriteString(stateEncoding)
+	buf.Write(data)
+	return buf.Bytes(), nil
+}
+
+// decodeState returns the state in the saved state v and the version of the saved state format.
+func decodeState(v []byte) (*State, int, error) {
+	s := newState()
+	if !bytes.HasPrefix(v, []byte(savedStateMagic)) {
+		if err := codec.Decode(&s, v); err != nil {
+			return nil, 0, err
+		}
+		return s, 0, nil
+	}
+
+	r := bytes.NewReader(v[len(savedStateMagic):])
+	version, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	l, err := binary.ReadUvarint(r)
+	if err != nil || l > uint64(r.Len()) {
+		return nil, 0, errors.New("invalid saved state encoding")
+	}
+
+	enc := make([]byte, l)
+	r.Read(enc)
+
+	data := v[len(v)-r.Len():]
+	switch string(enc) {
+	case GobEncoding:
+		err = codec.Decode(&s, data)
+	case JSONEncoding:
+		js := new(jsonState)
+		if err = json.Unmarshal(data, js); err == nil {
+			js.toState(s)
+		}
+	default:
+		err = fmt.Errorf("unknown saved state encoding %q", enc)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return s, int(version), nil
+}
+
+// loadState decodes the saved state of the game, upgrading it to the current version.
+func (g *Game) loadState(ctx context.Context) error {
+	s, version, err := decodeState(g.SavedState)
+	if err != nil {
+		return err
+	}
+
+	for ; version < savedStateVersion; version++ {
+		m, ok := migrations[version]
+		if !ok {
+			return fmt.Errorf("no migration from saved state version %d", version)
+		}
+
+		log.Debugf(ctx, "migrating saved state of game %d from version %d", g.ID, version)
+		if err = m(ctx, g, s); err != nil {
+			return err
+		}
+	}
+
+	if version > savedStateVersion {
+		return fmt.Errorf("saved state version %d is newer than supported version %d", version, savedStateVersion)
+	}
+
+	if s.TempData == nil {
+		s.TempData = new(TempData)
+	}
+	s.SavedLogLength = len(s.Log)
+	g.State = s
+	return nil
+}
+
+// jsonState is the JSON encoding of State.
+type jsonState struct {
+	Players         []*Player        `json:"players"`
+	Log             GameLog          `json:"log"`
+	Grid            grid             `json:"grid"`
+	Jewels          Card             `json:"jewels"`
+	TwoThiefVariant bool             `json:"twoThiefVariant"`
+	BotLevel        string           `json:"botLevel,omitempty"`
+	Bots            int              `json:"bots,omitempty"`
+	TeamPlay        bool             `json:"teamPlay,omitempty"`
+	Teams           [][]int          `json:"teams,omitempty"`
+	Scenario        *engine.Scenario `json:"scenario,omitempty"`
+	TimeControl     string           `json:"timeControl,omitempty"`
+	TimeLimit       int              `json:"timeLimit,omitempty"`
+	TimeoutAction   string           `json:"timeoutAction,omitempty"`
+	ClockStarted    time.Time        `json:"clockStarted"`
+	Rand            engine.Rand      `json:"rand"`
+	Ruleset         *engine.Ruleset  `json:"ruleset,omitempty"`
+	Analysis        *analysis        `json:"analysis,omitempty"`
+}
+
+func newJSONState(s *State) *jsonState {
+	js := &jsonState{
+		Log:             s.Log,
+		Grid:            s.Grid,
+		Jewels:          s.Jewels,
+		TwoThiefVariant: s.TwoThiefVariant,
+		BotLevel:        s.BotLevel,
+		Bots:            s.Bots,
+		TeamPlay:        s.TeamPlay,
+		Teams:           s.Teams,
+		Scenario:        s.Scenario,
+		TimeControl:     s.TimeControl,
+		TimeLimit:       s.TimeLimit,
+		TimeoutAction:   s.TimeoutAction,
+		ClockStarted:    s.ClockStarted,
+		Rand:            s.Rand,
+		Ruleset:         s.Ruleset,
+		Analysis:        s.Analysis,
+	}
+	for _, p := range s.Playerers {
+		js.Players = append(js.Players, p.(*Player))
+	}
+	return js
+}
+
+func (js *jsonState) toState(s *State) {
+	s.Log = js.Log
+	s.Grid = js.Grid
+	s.Jewels = js.Jewels
+	s.TwoThiefVariant = js.TwoThiefVariant
+	s.BotLevel = js.BotLevel
+	s.Bots = js.Bots
+	s.TeamPlay = js.TeamPlay
+	s.Teams = js.Teams
+	s.Scenario = js.Scenario
+	s.TimeControl = js.TimeControl
+	s.TimeLimit = js.TimeLimit
+	s.TimeoutAction = js.TimeoutAction
+	s.ClockStarted = js.ClockStarted
+	s.Rand = js.Rand
+	s.Ruleset = js.Ruleset
+	s.Analysis = js.Analysis
+	s.Playerers = make(game.Playerers, len(js.Players))
+	for i, p := range js.Players {
+		s.Playerers[i] = p
+	}
+}
+
+// entryTypes maps the stable names of log entry types to their types.
+var entryTypes = make(map[string]reflect.Type)
+
+// registerEntry registers the type of log entry e for gob encoding and, using name, for JSON encoding.
+// name must not change once games have been saved with it.
+func registerEntry(name string, e Entryer) {
+	gob.Register(e)
+	entryTypes[name] = reflect.TypeOf(e).Elem()
+}
+
+func entryName(e Entryer) (string, error) {
+	t := reflect.TypeOf(e).Elem()
+	for name, et := range entryTypes {
+		if et == t {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unregistered log entry type %T", e)
+}
+
+type jsonEntry struct {
+	Type  string          `json:"type"`
+	Entry json.RawMessage `json:"entry"`
+}
+
+// MarshalJSON encodes the entries of the log together with the names of their types.
+func (l GameLog) MarshalJSON() ([]byte, error) {
+	jes := make([]jsonEntry, len(l))
+	for i, e := range l {
+		name, err := entryName(e)
+		if err != nil {
+			return nil, err
+		}
+
+		if jes[i].Entry, err = json.Marshal(e); err != nil {
+			return nil, err
+		}
+		jes[i].Type = name
+	}
+	return json.Marshal(jes)
+}
+
+// UnmarshalJSON decodes entries encoded by MarshalJSON.
+func (l *GameLog) UnmarshalJSON(data []byte) error {
+	var jes []jsonEntry
+	if err := json.Unmarshal(data, &jes); err != nil {
+		return err
+	}
+
+	*l = make(GameLog, len(jes))
+	for i, je := range jes {
+		t, ok := entryTypes[je.Type]
+		if !ok {
+			return fmt.Errorf("unknown log entry type %q", je.Type)
+		}
+
+		e := reflect.New(t).Interface()
+		if err := json.Unmarshal(je.Entry, e); err != nil {
+			return err
+		}
+		(*l)[i] = e.(Entryer)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package got
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"github.com/SlothNinja/gt/engine"
+	"golang.org/x/net/context"
+)
+
+func TestMigrateV0(t *testing.T) {
+	created := time.Date(2019, time.March, 1, 12, 0, 0, 0, time.UTC)
+	migrated := func(id int64, r engine.Rand) engine.Rand {
+		g := &Game{Header: &game.Header{ID: id, CreatedAt: created}}
+		s := newState()
+		s.Rand = r
+		if err := migrateV0(context.Background(), g, s); err != nil {
+			t.Fatal(err)
+		}
+		return s.Rand
+	}
+
+	r := migrated(1, engine.Rand{})
+	switch {
+	case r == (engine.Rand{}):
+		t.Error("the migrated game has no random stream")
+	case migrated(1, engine.Rand{}) != r:
+		t.Error("migrating the same game twice seeds different streams")
+	case migrated(2, engine.Rand{}) == r:
+		t.Error("different games are seeded the same stream")
+	}
+
+	kept := engine.Rand{Seed: 7, Pos: 3}
+	if got := migrated(1, kept); got != kept {
+		t.Errorf("got stream %+v, want the saved stream %+v", got, kept)
+	}
+}
+
+// testEnvelope returns a saved state envelope of the given version holding data in the encoding enc.
+func testEnvelope(version uint64, enc string, data []byte) []byte {
+	buf := bytes.NewBufferString(savedStateMagic)
+	b := make([]byte, binary.MaxVarintLen64)
+	buf.Write(b[:binary.PutUvarint(b, version)])
+	buf.Write(b[:binary.PutUvarint(b, uint64(len(enc)))])
+	buf.WriteString(enc)
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+func TestStateEnvelope(t *testing.T) {
+	defer SetStateEncoding(stateEncoding)
+	pg := playGame(t, 2, 9)
+
+	for _, enc := range []string{GobEncoding, JSONEncoding} {
+		if err := SetStateEncoding(enc); err != nil {
+			t.Fatal(err)
+		}
+
+		v, err := encodeState(pg.State)
+		if err != nil {
+			t.Fatalf("%s: %v", enc, err)
+		}
+		if want := testEnvelope(savedStateVersion, enc, nil); !bytes.HasPrefix(v, want) {
+			t.Errorf("%s: got envelope beginning %q, want %q", enc, v[:len(want)], want)
+		}
+
+		s, version, err := decodeState(v)
+		switch {
+		case err != nil:
+			t.Fatalf("%s: %v", enc, err)
+		case version != savedStateVersion:
+			t.Errorf("%s: got version %d, want %d", enc, version, savedStateVersion)
+		case s.Rand != pg.Rand:
+			t.Errorf("%s: got random stream %+v, want %+v", enc, s.Rand, pg.Rand)
+		case !reflect.DeepEqual(s.Grid, pg.Grid):
+			t.Errorf("%s: the grids differ", enc)
+		case len(s.Log) != len(pg.Log):
+			t.Fatalf("%s: got %d log entries, want %d", enc, len(s.Log), len(pg.Log))
+		}
+		for i, e := range pg.Log {
+			if reflect.TypeOf(s.Log[i]) != reflect.TypeOf(e) {
+				t.Errorf("%s: got log entry %d of type %T, want %T", enc, i, s.Log[i], e)
+			}
+		}
+	}
+}
+
+func TestDecodeStateInvalid(t *testing.T) {
+	v, err := encodeState(newState())
+	if err != nil {
+		t.Fatal(err)
+	}
+	badMagic := append([]byte(nil), v...)
+	badMagic[len(savedStateMagic)-1] = 'X'
+
+	tests := []struct {
+		name string
+		v    []byte
+	}{
+		{"bad magic prefix", badMagic},
+		{"missing version", []byte(savedStateMagic)},
+		{"missing encoding", testEnvelope(savedStateVersion, "", nil)[:len(savedStateMagic)+1]},
+		{"encoding name too long", append(testEnvelope(savedStateVersion, "", nil)[:len(savedStateMagic)+1], 10, 'g')},
+		{"unknown encoding", testEnvelope(savedStateVersion, "xml", []byte("<state/>"))},
+		{"corrupt JSON", testEnvelope(savedStateVersion, JSONEncoding, []byte("{"))},
+	}
+
+	for _, tt := range tests {
+		if _, _, err := decodeState(tt.v); err == nil {
+			t.Errorf("%s: decoding succeeded", tt.name)
+		}
+	}
+}
+
+func TestLoadStateFutureVersion(t *testing.T) {
+	v, err := encodeState(newState())
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := len(testEnvelope(savedStateVersion, stateEncoding, nil))
+	future := testEnvelope(savedStateVersion+1, stateEncoding, v[prefix:])
+
+	if _, version, err := decodeState(future); err != nil || version != savedStateVersion+1 {
+		t.Fatalf("got version %d and error %v, want version %d", version, err, savedStateVersion+1)
+	}
+	g := &Game{Header: &game.Header{ID: 1, SavedState: future}}
+	if err := g.loadState(context.Background()); err == nil {
+		t.Error("loading saved state of a future version succeeded")
+	}
+	if g.State != nil {
+		t.Error("the game was given the state of a future version")
+	}
+}
+
+func TestRegisteredEntriesJSON(t *testing.T) {
+	if len(entryTypes) == 0 {
+		t.Fatal("no log entry types are registered")
+	}
+
+	for name, et := range entryTypes {
+		l := GameLog{reflect.New(et).Interface().(Entryer)}
+		data, err := json.Marshal(l)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		var got GameLog
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(got) != 1 || reflect.TypeOf(got[0]).Elem() != et {
+			t.Errorf("%s: got entries %+v, want one of type %v", name, got, et)
+		}
+	}
+
+	var l GameLog
+	if err := json.Unmarshal([]byte(`[{"type":"no-such-entry","entry":{}}]`), &l); err == nil {
+		t.Error("decoding an unregistered log entry type succeeded")
+	}
+}
@@ -0,0 +1,304 @@
+package got
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/user"
+	"github.com/SlothNinja/gt/engine"
+	"github.com/gin-gonic/gin"
+	"go.chromium.org/gae/service/datastore"
+	"golang.org/x/net/context"
+)
+
+func init() {
+	registerEntry("scenario", new(scenarioEntry))
+}
+
+// scenarios maps the names of the scenarios selectable when creating a solo game to the scenarios.
+var scenarios = make(map[string]*engine.Scenario)
+
+// RegisterScenario makes the scenario selectable, by its name, when creating a solo game.
+// It must be called before serving requests.
+func RegisterScenario(sc *engine.Scenario) error {
+	if sc.Name == "" {
+		return fmt.Errorf("a scenario must have a name")
+	}
+	if _, ok := scenarios[sc.Name]; ok {
+		return fmt.Errorf("scenario %q is already registered", sc.Name)
+	}
+	if err := sc.Validate(); err != nil {
+		return fmt.Errorf("scenario %q: %v", sc.Name, err)
+	}
+	scenarios[sc.Name] = sc
+	return nil
+}
+
+// LoadScenarios registers the scenarios defined by the files, named with the extension .json, in directory dir.
+// It must be called before serving requests.
+func LoadScenarios(dir string) error {
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		sc, err := readScenario(name)
+		if err != nil {
+			return err
+		}
+		if err = RegisterScenario(sc); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// readScenario reads the JSON encoding of a scenario from the file named name.
+func readScenario(name string) (*engine.Scenario, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := new(engine.Scenario)
+	if err = json.NewDecoder(f).Decode(sc); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return sc, nil
+}
+
+// isSolo indicates whether the game is a solo game of a scenario.
+func (g *Game) isSolo() bool {
+	return g.Scenario != nil
+}
+
+// scenarioFromForm sets the scenario of the game to the scenario named by the form, if any.
+func (g *Game) scenarioFromForm(ctx context.Context) error {
+	c := restful.GinFrom(ctx)
+	g.Scenario = nil
+
+	if name := c.PostForm("scenario"); name != "" {
+		sc, ok := scenarios[name]
+		if !ok {
+			return fmt.Errorf("unknown scenario %q", name)
+		}
+		g.Scenario = sc
+	}
+	return nil
+}
+
+// validateScenario checks the options of a solo game, which is played by its creator alone.
+func (g *Game) validateScenario() error {
+	switch {
+	case g.Bots > 0:
+		return fmt.Errorf("a solo game may not have bots")
+	case g.TeamPlay:
+		return fmt.Errorf("a solo game may not have teams")
+	case g.TimeControl != noTimeControl:
+		return fmt.Errorf("a solo game may not have a time control")
+	}
+	g.NumPlayers = 1
+	return nil
+}
+
+// setupScenario begins a solo game from the position defined by its scenario.
+func (g *Game) setupScenario(ctx context.Context) error {
+	p := g.Players()[0]
+	s, err := g.Scenario.NewState(p.ID())
+	if err != nil {
+		return err
+	}
+
+	g.setEngineState(s)
+	g.beginningOfPhaseReset()
+	g.newScenarioEntryFor(p)
+	return nil
+}
+
+// solvers returns the players who reached the goal of the scenario.
+func (g *Game) solvers() (ps Players) {
+	s := g.engineState()
+	for _, p := range g.Players() {
+		if g.Scenario.Solved(s, p.ID()) {
+			ps = append(ps, p)
+		}
+	}
+	return
+}
+
+type scenarioEntry struct {
+	*Entry
+	Scenario *engine.Scenario
+}
+
+func (g *Game) newScenarioEntryFor(p *Player) *scenarioEntry {
+	e := &scenarioEntry{
+		Entry:    g.newEntryFor(p),
+		Scenario: g.Scenario,
+	}
+	p.Log = append(p.Log, e)
+	g.Log = append(g.Log, e)
+	return e
+}
+
+func (e *scenarioEntry) HTML(g *Game) template.HTML {
+	return restful.HTML("%s began the puzzle %s.  The goal: %s.", g.NameByPID(e.PlayerID), e.Scenario.Name, e.Scenario.Goal)
+}
+
+func (e *scenarioEntry) replayOn(r *replay) (err error) {
+	if r.s, err = e.Scenario.NewState(e.PlayerID); err != nil {
+		return
+	}
+	r.turns = append(r.turns, r.s)
+	return
+}
+
+// bestScore records the best result of a user in a scenario.
+// It is keyed by the name of the scenario, within the key of the user.
+type bestScore struct {
+	Kind      string         `gae:"$kind,GOTBestScore"`
+	Scenario  string         `gae:"$id"`
+	Parent    *datastore.Key `gae:"$parent"`
+	Score     int
+	Solved    bool
+	GameID    int64
+	UpdatedAt time.Time
+}
+
+// better indicates whether b is a better result than b2: a solved scenario, otherwise a higher score.
+func (b *bestScore) better(b2 *bestScore) bool {
+	if b.Solved != b2.Solved {
+		return b.Solved
+	}
+	return b.Score > b2.Score
+}
+
+// bestScoreUpdate returns the best score of the player of a finished solo game, updated for the result
+// of the game, or nil if the player has done better before.
+func (g *Game) bestScoreUpdate(ctx context.Context) *bestScore {
+	p := g.Players()[0]
+	b := &bestScore{
+		Scenario:  g.Scenario.Name,
+		Parent:    datastore.KeyForObj(ctx, p.User()),
+		Score:     p.Score,
+		Solved:    g.Scenario.Solved(g.engineState(), p.ID()),
+		GameID:    g.ID,
+		UpdatedAt: time.Now(),
+	}
+
+	old := &bestScore{Scenario: b.Scenario, Parent: b.Parent}
+	switch err := datastore.Get(ctx, old); {
+	case err == datastore.ErrNoSuchEntity:
+		return b
+	case err != nil:
+		log.Warningf(ctx, "unable to get best score: %v", err)
+		return nil
+	case b.better(old):
+		return b
+	default:
+		return nil
+	}
+}
+
+// bestScores responds with the best results of the current user in each scenario played.
+func bestScores(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	cu := user.CurrentFrom(ctx)
+	if cu == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You must be logged in to view your best scores."})
+		return
+	}
+
+	var bs []*bestScore
+	q := datastore.NewQuery("GOTBestScore").Ancestor(datastore.KeyForObj(ctx, cu))
+	if err := datastore.GetAll(ctx, q, &bs); err != nil {
+		log.Errorf(ctx, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to get best scores"})
+		return
+	}
+
+	scores := make([]gin.H, len(bs))
+	for i, b := range bs {
+		scores[i] = gin.H{
+			"scenario":  b.Scenario,
+			"score":     b.Score,
+			"solved":    b.Solved,
+			"gameId":    b.GameID,
+			"updatedAt": b.UpdatedAt,
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"version": apiVersion, "scores": scores})
+}
+
+// authorScenario responds, as a downloadable file, with a scenario beginning from a position of the game.
+// The query gives the name of the scenario, the goal's points and turns, the player whose board and cards
+// the scenario uses, and, optionally, the log entry following which the position is taken.
+// Without an entry, the current position is used.
+func authorScenario(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	if !user.IsAdmin(ctx) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins may author scenarios."})
+		return
+	}
+
+	g := gameFrom(ctx)
+	if g == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		return
+	}
+
+	var goal engine.Goal
+	pid, err := strconv.Atoi(c.Query("player"))
+	if err == nil {
+		goal.Points, err = strconv.Atoi(c.Query("points"))
+	}
+	if err == nil {
+		goal.Turns, err = strconv.Atoi(c.Query("turns"))
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "player, points and turns must be numbers"})
+		return
+	}
+
+	s := g.engineState()
+	if entry := c.Query("entry"); entry != "" {
+		n, err := strconv.Atoi(entry)
+		if err == nil {
+			s, err = g.Replay(n)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unable to replay to entry %q: %v", entry, err)})
+			return
+		}
+	}
+
+	name := c.Query("name")
+	if name == "" {
+		name = fmt.Sprintf("game-%d-turn-%d", g.ID, s.Turn)
+	}
+
+	sc, err := engine.ScenarioFrom(s, pid, name, goal)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".json"))
+	c.JSON(http.StatusOK, sc)
+}
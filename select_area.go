@@ -9,9 +9,13 @@ import (
 	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
 	"bitbucket.org/SlothNinja/slothninja-games/sn/user"
+	"github.com/SlothNinja/gt/engine"
 	"golang.org/x/net/context"
 )
 
+// adminPlayerRow prefixes the index of the player selected for the admin player dialog.
+const adminPlayerRow = "admin-player-row-"
+
 func (g *Game) selectArea(ctx context.Context) (string, game.ActionType, error) {
 	log.Debugf(ctx, "Entering")
 	defer log.Debugf(ctx, "Exiting")
@@ -23,17 +27,7 @@ func (g *Game) selectArea(ctx context.Context) (string, game.ActionType, error)
 	switch cp := g.CurrentPlayer(); {
 	case g.Admin == "admin-header":
 		return "got/admin/header_dialog", game.Cache, nil
-	case g.Admin == "admin-player-row-0":
-		g.SelectedPlayerID = 0
-		return "got/admin/player_dialog", game.Cache, nil
-	case g.Admin == "admin-player-row-1":
-		g.SelectedPlayerID = 1
-		return "got/admin/player_dialog", game.Cache, nil
-	case g.Admin == "admin-player-row-2":
-		g.SelectedPlayerID = 2
-		return "got/admin/player_dialog", game.Cache, nil
-	case g.Admin == "admin-player-row-3":
-		g.SelectedPlayerID = 3
+	case strings.HasPrefix(g.Admin, adminPlayerRow):
 		return "got/admin/player_dialog", game.Cache, nil
 	case g.CanPlaceThief(ctx, cp):
 		template, err := g.placeThief(ctx)
@@ -67,6 +61,19 @@ func (g *Game) validateSelectArea(ctx context.Context) (err error) {
 	areaID := restful.GinFrom(ctx).PostForm("area")
 	switch splits := strings.Split(areaID, "-"); splits[0] {
 	case "admin":
+		if strings.HasPrefix(areaID, adminPlayerRow) {
+			var pid int
+			pid, err = strconv.Atoi(strings.TrimPrefix(areaID, adminPlayerRow))
+			switch {
+			case err != nil:
+				err = sn.NewVError("Received invalid player row.")
+				return
+			case g.PlayerByID(pid) == nil:
+				err = sn.NewVError("No player having row %d.", pid)
+				return
+			}
+			g.SelectedPlayerID = pid
+		}
 		g.Admin = areaID
 	case "area":
 		var row, col int
@@ -76,21 +83,19 @@ func (g *Game) validateSelectArea(ctx context.Context) (err error) {
 
 		switch {
 		case err != nil:
-		case row < rowA:
+		case row < 0:
 			err = sn.NewVError("Row too small")
-		case row > rowG:
-			err = sn.NewVError("Row too large")
-		case g.NumPlayers == 2 && row > rowF:
+		case row >= len(g.Grid):
 			err = sn.NewVError("Row too large")
-		case col < col1:
+		case col < 0:
 			err = sn.NewVError("Column too small")
-		case col > col8:
+		case col >= len(g.Grid[row]):
 			err = sn.NewVError("Column too large")
 		default:
 			g.SelectedAreaF = g.Grid[row][col]
 		}
 	case "card":
-		if cardType := toCType(strings.TrimPrefix(areaID, "card-")); cardType == noType {
+		if cardType := engine.ToCType(strings.TrimPrefix(areaID, "card-")); cardType == engine.NoType {
 			err = sn.NewVError("Received invalid card type.")
 		} else {
 			for i, card := range cp.Hand {
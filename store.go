@@ -0,0 +1,138 @@
+package got
+
+import (
+	"errors"
+	"time"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"go.chromium.org/gae/service/datastore"
+	"go.chromium.org/gae/service/memcache"
+	"golang.org/x/net/context"
+)
+
+var (
+	// ErrConflict indicates a game was saved by another request after it was loaded.
+	ErrConflict = errors.New("game state changed unexpectantly -- try again")
+
+	// ErrCacheMiss indicates no turn data is cached for a game.
+	ErrCacheMiss = errors.New("turn not cached")
+)
+
+// cacheExpiration is how long cached turn data is kept.
+const cacheExpiration = 30 * time.Minute
+
+// GameStore persists games and caches the data of turns in progress.
+type GameStore interface {
+	// Get loads the header, including the encoded state, of the game having g.ID.
+	Get(ctx context.Context, g *Game) error
+
+	// Create stores a new game, assigning its ID, together with the related entities returned by related.
+	Create(ctx context.Context, g *Game, related func(id int64) []interface{}) error
+
+	// Save stores the game together with the related entities es and uncaches the turn in progress.
+	// It returns ErrConflict if the stored game was updated after g was loaded.
+	Save(ctx context.Context, g *Game, es ...interface{}) error
+
+	// Cached returns the data cached for the turn in progress, or ErrCacheMiss if none is cached.
+	Cached(ctx context.Context, g *Game) ([]byte, error)
+
+	// Cache caches data for the turn in progress.
+	Cache(ctx context.Context, g *Game, v []byte) error
+
+	// Uncache removes any data cached for the turn in progress.
+	Uncache(ctx context.Context, g *Game) error
+
+	// Running returns the IDs of the games in progress.
+	Running(ctx context.Context) ([]int64, error)
+}
+
+var store GameStore = gaeStore{}
+
+// SetStore replaces the store used to persist games.
+func SetStore(s GameStore) {
+	store = s
+}
+
+// gaeStore persists games using the App Engine datastore and memcache.
+type gaeStore struct{}
+
+func (gaeStore) Get(ctx context.Context, g *Game) error {
+	return datastore.Get(ctx, g.Header)
+}
+
+func (gaeStore) Create(ctx context.Context, g *Game, related func(id int64) []interface{}) error {
+	return datastore.RunInTransaction(ctx, func(tc context.Context) error {
+		if err := datastore.Put(tc, g.Header); err != nil {
+			return err
+		}
+
+		if es := related(g.ID); len(es) > 0 {
+			return datastore.Put(tc, es)
+		}
+		return nil
+	}, &datastore.TransactionOptions{XG: true})
+}
+
+func (s gaeStore) Save(ctx context.Context, g *Game, es ...interface{}) error {
+	return datastore.RunInTransaction(ctx, func(tc context.Context) error {
+		oldG := New(tc)
+		if ok := datastore.PopulateKey(oldG.Header, datastore.KeyForObj(tc, g.Header)); !ok {
+			return errors.New("unable to populate game with key")
+		}
+
+		if err := datastore.Get(tc, oldG.Header); err != nil {
+			return err
+		}
+
+		if oldG.UpdatedAt != g.UpdatedAt {
+			return ErrConflict
+		}
+
+		if err := datastore.Put(tc, append(es, g.Header)); err != nil {
+			return err
+		}
+		return s.Uncache(tc, g)
+	}, &datastore.TransactionOptions{XG: true})
+}
+
+func (gaeStore) Cached(ctx context.Context, g *Game) ([]byte, error) {
+	item, err := memcache.GetKey(ctx, g.UndoKey(ctx))
+	switch {
+	case err == memcache.ErrCacheMiss:
+		return nil, ErrCacheMiss
+	case err != nil:
+		return nil, err
+	default:
+		return item.Value(), nil
+	}
+}
+
+func (gaeStore) Cache(ctx context.Context, g *Game, v []byte) error {
+	item := memcache.NewItem(ctx, g.UndoKey(ctx)).SetExpiration(cacheExpiration).SetValue(v)
+	return memcache.Set(ctx, item)
+}
+
+func (gaeStore) Uncache(ctx context.Context, g *Game) error {
+	if err := memcache.Delete(ctx, g.UndoKey(ctx)); err != nil && err != memcache.ErrCacheMiss {
+		return err
+	}
+	return nil
+}
+
+func (gaeStore) Running(ctx context.Context) ([]int64, error) {
+	q := datastore.NewQuery(datastore.KeyForObj(ctx, New(ctx).Header).Kind()).
+		Ancestor(pk(ctx)).
+		Eq("Status", game.Running).
+		KeysOnly(true)
+
+	var ks []*datastore.Key
+	if err := datastore.GetAll(ctx, q, &ks); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, len(ks))
+	for i, k := range ks {
+		ids[i] = k.IntID()
+	}
+	return ids, nil
+}
@@ -0,0 +1,174 @@
+package got
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/codec"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"golang.org/x/net/context"
+)
+
+const gameFileExt = ".game"
+
+// fileStore is a GameStore keeping each game in a file of its directory.
+// Turns in progress are cached in memory and related entities, such as stats and contests, are not kept.
+type fileStore struct {
+	sync.Mutex
+	dir   string
+	cache *turnCache
+}
+
+// NewFileStore returns a GameStore keeping games in files of the directory dir, for use in local development.
+func NewFileStore(dir string) (GameStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &fileStore{dir: dir, cache: newTurnCache()}, nil
+}
+
+func (s *fileStore) path(id int64) string {
+	return filepath.Join(s.dir, strconv.FormatInt(id, 10)+gameFileExt)
+}
+
+func (s *fileStore) Get(ctx context.Context, g *Game) error {
+	s.Lock()
+	defer s.Unlock()
+
+	v, err := ioutil.ReadFile(s.path(g.ID))
+	if err != nil {
+		return err
+	}
+	return codec.Decode(g.Header, v)
+}
+
+// write atomically replaces the file of the game having header h.
+func (s *fileStore) write(h *game.Header) error {
+	v, err := codec.Encode(h)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path(h.ID) + ".tmp"
+	if err = ioutil.WriteFile(tmp, v, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(h.ID))
+}
+
+// read returns the header of the game having id.
+func (s *fileStore) read(id int64) (*game.Header, error) {
+	v, err := ioutil.ReadFile(s.path(id))
+	if err != nil {
+		return nil, err
+	}
+
+	h := new(game.Header)
+	if err = codec.Decode(h, v); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// lastID returns the largest id of the games in the directory.
+func (s *fileStore) lastID() (id int64, err error) {
+	fs, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, f := range fs {
+		if !strings.HasSuffix(f.Name(), gameFileExt) {
+			continue
+		}
+
+		if i, err := strconv.ParseInt(strings.TrimSuffix(f.Name(), gameFileExt), 10, 64); err == nil && i > id {
+			id = i
+		}
+	}
+	return id, nil
+}
+
+func (s *fileStore) Create(ctx context.Context, g *Game, related func(id int64) []interface{}) error {
+	s.Lock()
+	defer s.Unlock()
+
+	id, err := s.lastID()
+	if err != nil {
+		return err
+	}
+
+	g.ID = id + 1
+	g.CreatedAt = time.Now()
+	g.UpdatedAt = g.CreatedAt
+	related(g.ID)
+	return s.write(g.Header)
+}
+
+func (s *fileStore) Save(ctx context.Context, g *Game, es ...interface{}) error {
+	s.Lock()
+	defer s.Unlock()
+
+	old, err := s.read(g.ID)
+	if err != nil {
+		return err
+	}
+
+	if !old.UpdatedAt.Equal(g.UpdatedAt) {
+		return ErrConflict
+	}
+
+	h := updated(g.Header)
+	if err = s.write(h); err != nil {
+		return fmt.Errorf("unable to save game %d: %v", g.ID, err)
+	}
+	g.UpdatedAt = h.UpdatedAt
+	return s.Uncache(ctx, g)
+}
+
+func (s *fileStore) Cached(ctx context.Context, g *Game) ([]byte, error) {
+	return s.cache.get(undoKey(ctx, g))
+}
+
+func (s *fileStore) Cache(ctx context.Context, g *Game, v []byte) error {
+	s.cache.set(undoKey(ctx, g), v)
+	return nil
+}
+
+func (s *fileStore) Uncache(ctx context.Context, g *Game) error {
+	s.cache.delete(undoKey(ctx, g))
+	return nil
+}
+
+func (s *fileStore) Running(ctx context.Context) ([]int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	fs, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	for _, f := range fs {
+		id, err := strconv.ParseInt(strings.TrimSuffix(f.Name(), gameFileExt), 10, 64)
+		if !strings.HasSuffix(f.Name(), gameFileExt) || err != nil {
+			continue
+		}
+
+		h, err := s.read(id)
+		if err != nil {
+			return nil, err
+		}
+		if h.Status == game.Running {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,175 @@
+package got
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/codec"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/user"
+	"golang.org/x/net/context"
+)
+
+// memStore is a GameStore keeping games in memory.
+// Related entities, such as stats and contests, are not kept.
+type memStore struct {
+	sync.Mutex
+	games  map[int64][]byte
+	lastID int64
+	cache  *turnCache
+}
+
+// NewMemoryStore returns a GameStore keeping games in memory, for use in tests and local development.
+func NewMemoryStore() GameStore {
+	return &memStore{games: make(map[int64][]byte), cache: newTurnCache()}
+}
+
+func (s *memStore) Get(ctx context.Context, g *Game) error {
+	s.Lock()
+	defer s.Unlock()
+
+	v, ok := s.games[g.ID]
+	if !ok {
+		return fmt.Errorf("no game having id %d", g.ID)
+	}
+	return codec.Decode(g.Header, v)
+}
+
+func (s *memStore) Create(ctx context.Context, g *Game, related func(id int64) []interface{}) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.lastID++
+	g.ID = s.lastID
+	g.CreatedAt = time.Now()
+	g.UpdatedAt = g.CreatedAt
+	related(g.ID)
+
+	v, err := codec.Encode(g.Header)
+	if err != nil {
+		return err
+	}
+	s.games[g.ID] = v
+	return nil
+}
+
+func (s *memStore) Save(ctx context.Context, g *Game, es ...interface{}) error {
+	s.Lock()
+	defer s.Unlock()
+
+	v, ok := s.games[g.ID]
+	if !ok {
+		return fmt.Errorf("no game having id %d", g.ID)
+	}
+
+	old := new(game.Header)
+	if err := codec.Decode(old, v); err != nil {
+		return err
+	}
+
+	if !old.UpdatedAt.Equal(g.UpdatedAt) {
+		return ErrConflict
+	}
+
+	h := updated(g.Header)
+	v, err := codec.Encode(h)
+	if err != nil {
+		return err
+	}
+	s.games[g.ID] = v
+	g.UpdatedAt = h.UpdatedAt
+	return s.Uncache(ctx, g)
+}
+
+func (s *memStore) Cached(ctx context.Context, g *Game) ([]byte, error) {
+	return s.cache.get(undoKey(ctx, g))
+}
+
+func (s *memStore) Cache(ctx context.Context, g *Game, v []byte) error {
+	s.cache.set(undoKey(ctx, g), v)
+	return nil
+}
+
+func (s *memStore) Uncache(ctx context.Context, g *Game) error {
+	s.cache.delete(undoKey(ctx, g))
+	return nil
+}
+
+// updated returns a copy of header h updated now, so that h is updated only once the copy is stored.
+func updated(h *game.Header) *game.Header {
+	c := *h
+	c.UpdatedAt = time.Now()
+	return &c
+}
+
+// undoKey returns the key caching the turn in progress of the current user in game g.
+func undoKey(ctx context.Context, g *Game) string {
+	var uid int64
+	if cu := user.CurrentFrom(ctx); cu != nil {
+		uid = cu.ID
+	}
+	return fmt.Sprintf("game-%d/uid-%d", g.ID, uid)
+}
+
+// turnCache holds the data of turns in progress in memory until they expire.
+type turnCache struct {
+	sync.Mutex
+	items map[string]cacheItem
+}
+
+type cacheItem struct {
+	value   []byte
+	expires time.Time
+}
+
+func newTurnCache() *turnCache {
+	return &turnCache{items: make(map[string]cacheItem)}
+}
+
+func (c *turnCache) get(key string) ([]byte, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	item, ok := c.items[key]
+	switch {
+	case !ok:
+		return nil, ErrCacheMiss
+	case time.Now().After(item.expires):
+		delete(c.items, key)
+		return nil, ErrCacheMiss
+	default:
+		return item.value, nil
+	}
+}
+
+func (c *turnCache) set(key string, v []byte) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.items[key] = cacheItem{value: v, expires: time.Now().Add(cacheExpiration)}
+}
+
+func (c *turnCache) delete(key string) {
+	c.Lock()
+	defer c.Unlock()
+
+	delete(c.items, key)
+}
+
+func (s *memStore) Running(ctx context.Context) ([]int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	var ids []int64
+	for id, v := range s.games {
+		h := new(game.Header)
+		if err := codec.Decode(h, v); err != nil {
+			return nil, err
+		}
+		if h.Status == game.Running {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
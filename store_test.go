@@ -0,0 +1,206 @@
+package got
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"golang.org/x/net/context"
+)
+
+// testStores returns the stores kept without App Engine, and a function removing any files they keep.
+func testStores(t *testing.T) (map[string]GameStore, func()) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "got-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err := NewFileStore(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return map[string]GameStore{"memory": NewMemoryStore(), "file": fs}, func() { os.RemoveAll(dir) }
+}
+
+func testGame(title string, status game.Status) *Game {
+	return &Game{Header: &game.Header{Title: title, Status: status}, State: newState()}
+}
+
+func noRelated(int64) []interface{} { return nil }
+
+func TestStoreCreateGet(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			var ids []int64
+			for _, title := range []string{"first", "second"} {
+				g := testGame(title, game.Running)
+				if err := s.Create(ctx, g, noRelated); err != nil {
+					t.Fatal(err)
+				}
+				if g.UpdatedAt.IsZero() || !g.UpdatedAt.Equal(g.CreatedAt) {
+					t.Errorf("created game has CreatedAt %v and UpdatedAt %v", g.CreatedAt, g.UpdatedAt)
+				}
+				ids = append(ids, g.ID)
+
+				got := &Game{Header: &game.Header{ID: g.ID}}
+				if err := s.Get(ctx, got); err != nil {
+					t.Fatal(err)
+				}
+				if got.Title != title || !got.UpdatedAt.Equal(g.UpdatedAt) {
+					t.Errorf("got game %q updated %v, want %q updated %v", got.Title, got.UpdatedAt, title, g.UpdatedAt)
+				}
+			}
+
+			if ids[0] == ids[1] {
+				t.Errorf("both games have id %d", ids[0])
+			}
+		})
+	}
+}
+
+func TestStoreSaveConflict(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			g := testGame("original", game.Running)
+			if err := s.Create(ctx, g, noRelated); err != nil {
+				t.Fatal(err)
+			}
+
+			// Two requests load the game.
+			g1, g2 := &Game{Header: &game.Header{ID: g.ID}}, &Game{Header: &game.Header{ID: g.ID}}
+			for _, lg := range []*Game{g1, g2} {
+				if err := s.Get(ctx, lg); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			loaded := g1.UpdatedAt
+			g1.Title = "first"
+			if err := s.Save(ctx, g1); err != nil {
+				t.Fatalf("saving the first change: %v", err)
+			}
+			if !g1.UpdatedAt.After(loaded) {
+				t.Errorf("saving left UpdatedAt at %v", g1.UpdatedAt)
+			}
+
+			// The second request saves a change to the game it loaded before the first change was saved.
+			g2.Title = "second"
+			stale := g2.UpdatedAt
+			if err := s.Save(ctx, g2); err != ErrConflict {
+				t.Fatalf("saving a stale game: got error %v, want %v", err, ErrConflict)
+			}
+			if !g2.UpdatedAt.Equal(stale) {
+				t.Errorf("a failed save changed UpdatedAt from %v to %v", stale, g2.UpdatedAt)
+			}
+
+			got := &Game{Header: &game.Header{ID: g.ID}}
+			if err := s.Get(ctx, got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Title != "first" || !got.UpdatedAt.Equal(g1.UpdatedAt) {
+				t.Errorf("got game %q updated %v, want %q updated %v", got.Title, got.UpdatedAt, "first", g1.UpdatedAt)
+			}
+
+			// Once reloaded, the second request may save.
+			got.Title = "second"
+			if err := s.Save(ctx, got); err != nil {
+				t.Errorf("saving the reloaded game: %v", err)
+			}
+		})
+	}
+}
+
+func TestStoreCache(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			g := testGame("cached", game.Running)
+			if err := s.Create(ctx, g, noRelated); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := s.Cached(ctx, g); err != ErrCacheMiss {
+				t.Fatalf("got error %v, want %v", err, ErrCacheMiss)
+			}
+
+			want := []byte("turn in progress")
+			if err := s.Cache(ctx, g, want); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := s.Cached(ctx, g); err != nil || !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %q and error %v, want %q", got, err, want)
+			}
+
+			// Saving the game ends the turn in progress.
+			if err := s.Save(ctx, g); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Cached(ctx, g); err != ErrCacheMiss {
+				t.Errorf("after saving: got error %v, want %v", err, ErrCacheMiss)
+			}
+		})
+	}
+}
+
+func TestStoreRunning(t *testing.T) {
+	stores, cleanup := testStores(t)
+	defer cleanup()
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			var want []int64
+			for _, status := range []game.Status{game.Running, game.Completed, game.Running} {
+				g := testGame("game", status)
+				if err := s.Create(ctx, g, noRelated); err != nil {
+					t.Fatal(err)
+				}
+				if status == game.Running {
+					want = append(want, g.ID)
+				}
+			}
+
+			got, err := s.Running(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !sameIDs(got, want) {
+				t.Errorf("got running games %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// sameIDs indicates whether ids1 and ids2 list the same ids in any order.
+func sameIDs(ids1, ids2 []int64) bool {
+	if len(ids1) != len(ids2) {
+		return false
+	}
+
+	count := make(map[int64]int)
+	for _, id := range ids1 {
+		count[id]++
+	}
+	for _, id := range ids2 {
+		if count[id]--; count[id] < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+package got
+
+import (
+	"fmt"
+	"sort"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/contest"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
+	"github.com/SlothNinja/gt/engine"
+	"go.chromium.org/gae/service/datastore"
+	"golang.org/x/net/context"
+)
+
+// isTeamGame indicates whether the players are divided into teams.
+func (g *Game) isTeamGame() bool {
+	return len(g.Teams) != 0
+}
+
+func (g *Game) validateTeams() error {
+	if g.TeamPlay && (g.NumPlayers < 4 || g.NumPlayers%2 != 0) {
+		return fmt.Errorf("a team game must have four or six players, but has %d", g.NumPlayers)
+	}
+	return nil
+}
+
+// teams returns the players of each team.  Without teams, each player forms a team alone.
+func (g *Game) teams() []Players {
+	if !g.isTeamGame() {
+		ts := make([]Players, 0, g.NumPlayers)
+		for _, p := range g.Players() {
+			ts = append(ts, Players{p})
+		}
+		return ts
+	}
+
+	ts := make([]Players, len(g.Teams))
+	for i, pids := range g.Teams {
+		for _, pid := range pids {
+			if p := g.PlayerByID(pid); p != nil {
+				ts[i] = append(ts[i], p)
+			}
+		}
+	}
+	return ts
+}
+
+// score returns the sum of the scores of the members of the team.
+func (ps Players) score() (score int) {
+	for _, p := range ps {
+		score += p.Score
+	}
+	return
+}
+
+// lamps returns the number of lamps held by the members of the team.
+func (ps Players) lamps() (n int) {
+	for _, p := range ps {
+		n += engine.LampCount(p.Hand...)
+	}
+	return
+}
+
+// camels returns the number of camels held by the members of the team.
+func (ps Players) camels() (n int) {
+	for _, p := range ps {
+		n += engine.CamelCount(p.Hand...)
+	}
+	return
+}
+
+// cards returns the number of cards held by the members of the team.
+func (ps Players) cards() (n int) {
+	for _, p := range ps {
+		n += len(p.Hand)
+	}
+	return
+}
+
+// compareTeams compares teams by summed score, breaking ties by summed lamps, then camels, then cards.
+func compareTeams(t1, t2 Players) game.Comparison {
+	for _, d := range []int{
+		t1.score() - t2.score(),
+		t1.lamps() - t2.lamps(),
+		t1.camels() - t2.camels(),
+		t1.cards() - t2.cards(),
+	} {
+		switch {
+		case d < 0:
+			return game.LessThan
+		case d > 0:
+			return game.GreaterThan
+		}
+	}
+	return game.EqualTo
+}
+
+// sortedTeams returns the teams ordered from first to last place.
+func (g *Game) sortedTeams() []Players {
+	ts := g.teams()
+	sort.SliceStable(ts, func(i, j int) bool {
+		return compareTeams(ts[i], ts[j]) == game.GreaterThan
+	})
+	return ts
+}
+
+// teamRating returns the rating of the team as a single competitor: the mean rating of its members.
+func teamRating(t Players) (r, rd float64) {
+	for _, p := range t {
+		r += p.Rating().R
+		rd += p.Rating().RD
+	}
+	n := float64(len(t))
+	return r / n, rd / n
+}
+
+// determineTeamPlaces places the teams by summed score, treating each team as a single competitor.
+// Every member of a team shares the team's results against each opposing team.
+func (g *Game) determineTeamPlaces(ctx context.Context) contest.Places {
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	ts := g.sortedTeams()
+	var players Players
+	for _, t := range ts {
+		players = append(players, t...)
+	}
+	g.setPlayers(players)
+
+	places := make(contest.Places, 0)
+	rmap := make(contest.ResultsMap, 0)
+	for i, t1 := range ts {
+		results := make(contest.Results, 0)
+		tie := false
+		for j, t2 := range ts {
+			if i == j {
+				continue
+			}
+
+			r, rd := teamRating(t2)
+			result := &contest.Result{
+				GameID: g.ID,
+				Type:   g.Type,
+				R:      r,
+				RD:     rd,
+			}
+			switch compareTeams(t1, t2) {
+			case game.GreaterThan:
+				result.Outcome = 1
+			case game.LessThan:
+				result.Outcome = 0
+			case game.EqualTo:
+				result.Outcome = 0.5
+				tie = true
+			}
+			results = append(results, result)
+		}
+
+		for _, p := range t1 {
+			rmap[datastore.KeyForObj(ctx, p.User())] = results
+		}
+		if !tie {
+			places = append(places, rmap)
+			rmap = make(contest.ResultsMap, 0)
+		} else if i == len(ts)-1 {
+			places = append(places, rmap)
+		}
+	}
+	return places
+}
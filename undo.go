@@ -0,0 +1,151 @@
+package got
+
+import (
+	"bitbucket.org/SlothNinja/slothninja-games/sn"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/codec"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"golang.org/x/net/context"
+)
+
+// undoStack stores a snapshot of the game following each cached step of the current turn.
+// Current indexes the snapshot being shown; -1 indicates the state stored in the datastore.
+type undoStack struct {
+	Current   int
+	Snapshots [][]byte
+}
+
+func newUndoStack() *undoStack {
+	return &undoStack{Current: -1}
+}
+
+// undoStackFor returns the undo stack cached for the game, or an empty stack if none is cached.
+func undoStackFor(ctx context.Context, g *Game) (*undoStack, error) {
+	v, err := store.Cached(ctx, g)
+	switch {
+	case err == ErrCacheMiss:
+		return newUndoStack(), nil
+	case err != nil:
+		return nil, err
+	}
+
+	// Decode into a zero stack, as the encoding omits a Current of zero.
+	s := new(undoStack)
+	if err = codec.Decode(s, v); err != nil {
+		log.Warningf(ctx, "discarding undo stack: %v", err)
+		return newUndoStack(), nil
+	}
+	return s, nil
+}
+
+// save caches the undo stack for the game, removing it from the cache if it is empty.
+func (s *undoStack) save(ctx context.Context, g *Game) error {
+	if len(s.Snapshots) == 0 {
+		return store.Uncache(ctx, g)
+	}
+
+	v, err := codec.Encode(s)
+	if err != nil {
+		return err
+	}
+	return store.Cache(ctx, g, v)
+}
+
+// current returns the snapshot being shown, or nil if the stored state is being shown.
+func (s *undoStack) current() []byte {
+	if s.Current < 0 || s.Current >= len(s.Snapshots) {
+		return nil
+	}
+	return s.Snapshots[s.Current]
+}
+
+// push adds a snapshot following the one being shown, discarding any snapshots that could be redone.
+func (s *undoStack) push(v []byte) {
+	s.Snapshots = append(s.Snapshots[:s.Current+1], v)
+	s.Current = len(s.Snapshots) - 1
+}
+
+// CanUndo indicates whether a step of the current turn can be undone.
+func (s *undoStack) CanUndo() bool {
+	return s.Current >= 0
+}
+
+// CanRedo indicates whether an undone step of the current turn can be redone.
+func (s *undoStack) CanRedo() bool {
+	return s.Current < len(s.Snapshots)-1
+}
+
+// updateUndoStack records the result of an action of type act in the undo stack for the game.
+func (g *Game) updateUndoStack(ctx context.Context, act game.ActionType) error {
+	s, err := undoStackFor(ctx, g)
+	if err != nil {
+		return err
+	}
+
+	switch act {
+	case game.Cache:
+		v, err := codec.Encode(g)
+		if err != nil {
+			return err
+		}
+		s.push(v)
+	case game.Undo:
+		if !s.CanUndo() {
+			return sn.NewVError("There is nothing to undo.")
+		}
+		s.Current--
+	case game.Redo:
+		if !s.CanRedo() {
+			return sn.NewVError("There is nothing to redo.")
+		}
+		s.Current++
+	case game.Reset:
+		s = newUndoStack()
+	default:
+		return nil
+	}
+	return s.save(ctx, g)
+}
+
+func (g *Game) undoStep(ctx context.Context) (string, game.ActionType, error) {
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	if !g.CUserIsCPlayerOrAdmin(ctx) {
+		return "", game.None, sn.NewVError("Only the current player may perform this action.")
+	}
+
+	if cp := g.CurrentPlayer(); cp != nil {
+		restful.AddNoticef(ctx, "%s undid a step.", g.NameFor(cp))
+	}
+	return "", game.Undo, nil
+}
+
+func (g *Game) redoStep(ctx context.Context) (string, game.ActionType, error) {
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	if !g.CUserIsCPlayerOrAdmin(ctx) {
+		return "", game.None, sn.NewVError("Only the current player may perform this action.")
+	}
+
+	if cp := g.CurrentPlayer(); cp != nil {
+		restful.AddNoticef(ctx, "%s redid a step.", g.NameFor(cp))
+	}
+	return "", game.Redo, nil
+}
+
+func (g *Game) resetTurn(ctx context.Context) (string, game.ActionType, error) {
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	if !g.CUserIsCPlayerOrAdmin(ctx) {
+		return "", game.None, sn.NewVError("Only the current player may perform this action.")
+	}
+
+	if cp := g.CurrentPlayer(); cp != nil {
+		restful.AddNoticef(ctx, "%s reset turn.", g.NameFor(cp))
+	}
+	return "", game.Reset, nil
+}
@@ -0,0 +1,151 @@
+package got
+
+import (
+	"bytes"
+	"testing"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/codec"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"golang.org/x/net/context"
+)
+
+// withStore replaces the store with s, returning a function restoring the original.
+func withStore(s GameStore) func() {
+	old := store
+	SetStore(s)
+	return func() { SetStore(old) }
+}
+
+func TestMcGetStaleTurn(t *testing.T) {
+	defer withStore(NewMemoryStore())()
+
+	ctx := context.Background()
+	g := testGame("stale", game.Running)
+	if err := store.Create(ctx, g, noRelated); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := codec.Encode(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newUndoStack()
+	s.push(v)
+	s.push(v)
+
+	// The game is saved by another request, say, the cron timing out the turn, which leaves the turn cached.
+	if err := store.Save(ctx, g); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.save(ctx, g); err != nil {
+		t.Fatal(err)
+	}
+
+	got := &Game{Header: &game.Header{ID: g.ID}, State: newState()}
+	if err := mcGet(ctx, got); err != ErrCacheMiss {
+		t.Fatalf("got error %v, want %v", err, ErrCacheMiss)
+	}
+	if _, err := store.Cached(ctx, g); err != ErrCacheMiss {
+		t.Errorf("the stale turn remains cached: got error %v, want %v", err, ErrCacheMiss)
+	}
+}
+
+func TestUndoStack(t *testing.T) {
+	s := newUndoStack()
+	if s.current() != nil || s.CanUndo() || s.CanRedo() {
+		t.Fatal("an empty stack shows a snapshot rather than the stored state")
+	}
+
+	a, b, c := []byte("a"), []byte("b"), []byte("c")
+	for _, v := range [][]byte{a, b, c} {
+		s.push(v)
+	}
+	if !bytes.Equal(s.current(), c) || !s.CanUndo() || s.CanRedo() {
+		t.Fatalf("after three steps: shows %q, can undo %t, can redo %t", s.current(), s.CanUndo(), s.CanRedo())
+	}
+
+	s.Current -= 3
+	if s.current() != nil || s.CanUndo() || !s.CanRedo() {
+		t.Fatalf("after undoing every step: shows %q, can undo %t, can redo %t", s.current(), s.CanUndo(), s.CanRedo())
+	}
+
+	// A new step after undoing discards the steps that could be redone.
+	s.Current++
+	d := []byte("d")
+	s.push(d)
+	if len(s.Snapshots) != 2 || !bytes.Equal(s.Snapshots[0], a) || !bytes.Equal(s.current(), d) || s.CanRedo() {
+		t.Errorf("after a new step: snapshots %q showing %q, can redo %t", s.Snapshots, s.current(), s.CanRedo())
+	}
+}
+
+func TestUpdateUndoStack(t *testing.T) {
+	defer withStore(NewMemoryStore())()
+
+	ctx := context.Background()
+	g := testGame("undo", game.Running)
+	if err := store.Create(ctx, g, noRelated); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, act := range []game.ActionType{game.Undo, game.Redo} {
+		if err := g.updateUndoStack(ctx, act); err == nil {
+			t.Errorf("action %v on an empty stack succeeded", act)
+		}
+	}
+
+	// The stack is cached following each step, including when showing its first snapshot.
+	steps := []struct {
+		act     game.ActionType
+		current int
+		size    int
+	}{
+		{game.Cache, 0, 1},
+		{game.Cache, 1, 2},
+		{game.Undo, 0, 2},
+		{game.Undo, -1, 2},
+		{game.Redo, 0, 2},
+		{game.Cache, 1, 2},
+		{game.Reset, -1, 0},
+	}
+	for i, step := range steps {
+		if err := g.updateUndoStack(ctx, step.act); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+
+		s, err := undoStackFor(ctx, g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Current != step.current || len(s.Snapshots) != step.size {
+			t.Errorf("step %d: got stack at %d of %d snapshots, want %d of %d", i, s.Current, len(s.Snapshots), step.current, step.size)
+		}
+	}
+
+	if _, err := store.Cached(ctx, g); err != ErrCacheMiss {
+		t.Errorf("after reset: got error %v, want %v", err, ErrCacheMiss)
+	}
+}
+
+func TestUndoStackClearedOnSave(t *testing.T) {
+	defer withStore(NewMemoryStore())()
+
+	ctx := context.Background()
+	g := testGame("save", game.Running)
+	if err := store.Create(ctx, g, noRelated); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.updateUndoStack(ctx, game.Cache); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Save(ctx, g); err != nil {
+		t.Fatal(err)
+	}
+	s, err := undoStackFor(ctx, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.CanUndo() || s.CanRedo() || len(s.Snapshots) != 0 {
+		t.Errorf("after saving: got stack at %d of %d snapshots", s.Current, len(s.Snapshots))
+	}
+}
@@ -0,0 +1,262 @@
+package got
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/user"
+	"github.com/gin-gonic/gin"
+	"go.chromium.org/gae/service/datastore"
+	"golang.org/x/net/context"
+)
+
+const vacationKind = "GOTVacation"
+
+// Limits of vacations.  A user may be on vacation for at most maxVacationPerPeriod of any vacationPeriod,
+// so that vacations cannot be chained to stall a game.
+const (
+	maxVacation          = 30 * 24 * time.Hour
+	vacationHorizon      = 365 * 24 * time.Hour
+	vacationPeriod       = 365 * 24 * time.Hour
+	maxVacationPerPeriod = 60 * 24 * time.Hour
+	vacationDateForm     = "2006-01-02"
+)
+
+// vacation is a period during which a user is away.  The clocks of the user's turns are paused
+// and turn notifications are not sent.  It is kept within the key of the user.
+type vacation struct {
+	Kind   string         `gae:"$kind,GOTVacation"`
+	ID     int64          `gae:"$id"`
+	Parent *datastore.Key `gae:"$parent"`
+	Start  time.Time
+	End    time.Time
+}
+
+func (v *vacation) includes(t time.Time) bool {
+	return !t.Before(v.Start) && t.Before(v.End)
+}
+
+func (v *vacation) overlaps(v2 *vacation) bool {
+	return v.Start.Before(v2.End) && v2.Start.Before(v.End)
+}
+
+// vacations are sorted by start.
+type vacations []*vacation
+
+func (vs vacations) Len() int           { return len(vs) }
+func (vs vacations) Swap(i, j int)      { vs[i], vs[j] = vs[j], vs[i] }
+func (vs vacations) Less(i, j int) bool { return vs[i].Start.Before(vs[j].Start) }
+
+// vacationsOf returns the vacations of user u ending after time since, sorted by start.
+// Vacations that cannot be retrieved are ignored, so that they never block play.
+func vacationsOf(ctx context.Context, u *user.User, since time.Time) vacations {
+	if u == nil {
+		return nil
+	}
+
+	var vs vacations
+	q := datastore.NewQuery(vacationKind).Ancestor(datastore.KeyForObj(ctx, u)).Gt("End", since)
+	if err := datastore.GetAll(ctx, q, &vs); err != nil {
+		log.Warningf(ctx, "unable to get vacations: %v", err)
+		return nil
+	}
+	sort.Sort(vs)
+	return vs
+}
+
+// onVacation indicates whether player p is on vacation at time t.
+func (g *Game) onVacation(ctx context.Context, p *Player, t time.Time) bool {
+	if p == nil || p.IsBot() {
+		return false
+	}
+
+	for _, v := range vacationsOf(ctx, p.User(), t) {
+		if v.includes(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// pausedUntil returns when an allowance of d, starting at start, runs out, given the clock is paused during vs.
+func (vs vacations) pausedUntil(start time.Time, d time.Duration) time.Time {
+	t := start
+	for _, v := range vs {
+		if !v.End.After(t) {
+			continue
+		}
+		if v.Start.After(t) {
+			gap := v.Start.Sub(t)
+			if d <= gap {
+				return t.Add(d)
+			}
+			d -= gap
+		}
+		t = v.End
+	}
+	return t.Add(d)
+}
+
+// during returns the time from start to end spent on vacations vs.  Vacations must not overlap.
+func (vs vacations) during(start, end time.Time) (d time.Duration) {
+	for _, v := range vs {
+		from, to := v.Start, v.End
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		if to.After(from) {
+			d += to.Sub(from)
+		}
+	}
+	return
+}
+
+// activeTime returns the time from start to end, less the time during vs.
+func (vs vacations) activeTime(start, end time.Time) time.Duration {
+	d := end.Sub(start)
+	t := start
+	for _, v := range vs {
+		from, to := v.Start, v.End
+		if from.Before(t) {
+			from = t
+		}
+		if to.After(end) {
+			to = end
+		}
+		if to.After(from) {
+			d -= to.Sub(from)
+			t = to
+		}
+	}
+	return d
+}
+
+// VacationBadgeFor outputs html marking a player on vacation.
+func (g *Game) VacationBadgeFor(ctx context.Context, p *Player) template.HTML {
+	if !g.onVacation(ctx, p, time.Now()) {
+		return ""
+	}
+	return restful.HTML("<span class='badge vacation' data-tip='On vacation: the clock is paused.'>Vacation</span>")
+}
+
+// vacationView is the JSON representation of a vacation.
+type vacationView struct {
+	ID    int64     `json:"id"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// showVacations responds with the current and future vacations of the current user.
+func showVacations(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	vs := vacationsOf(ctx, user.CurrentFrom(ctx), time.Now())
+	views := make([]vacationView, len(vs))
+	for i, v := range vs {
+		views[i] = vacationView{ID: v.ID, Start: v.Start, End: v.End}
+	}
+	c.JSON(http.StatusOK, gin.H{"version": apiVersion, "vacations": views})
+}
+
+// addVacation adds a vacation of the current user from the dates, formatted as 2006-01-02, of the form's start and end.
+// The vacation runs from the start of its first day, or from now if it starts today, to the end of its last.
+func addVacation(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	cu := user.CurrentFrom(ctx)
+	start, err := time.Parse(vacationDateForm, c.PostForm("start"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid start %q", c.PostForm("start"))})
+		return
+	}
+
+	last, err := time.Parse(vacationDateForm, c.PostForm("end"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid end %q", c.PostForm("end"))})
+		return
+	}
+
+	now := time.Now()
+	v := &vacation{Parent: datastore.KeyForObj(ctx, cu), Start: start, End: last.AddDate(0, 0, 1)}
+	if err = validateVacation(v, vacationsOf(ctx, cu, now.Add(-vacationPeriod)), now); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = datastore.Put(ctx, v); err != nil {
+		log.Errorf(ctx, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to save vacation"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"version": apiVersion, "vacation": vacationView{ID: v.ID, Start: v.Start, End: v.End}})
+}
+
+// validateVacation returns an error if vacation v may not be added at time now to the vacations vs of the user.
+// A vacation starting today starts now, so that it never pauses a clock that has already run.
+func validateVacation(v *vacation, vs vacations, now time.Time) error {
+	switch {
+	case v.Start.Before(now.Truncate(24 * time.Hour)):
+		return fmt.Errorf("a vacation may not start in the past")
+	case !v.End.After(v.Start):
+		return fmt.Errorf("a vacation must end after it starts")
+	case v.End.Sub(v.Start) > maxVacation:
+		return fmt.Errorf("a vacation may last at most %d days", maxVacation/(24*time.Hour))
+	case !v.End.After(now):
+		return fmt.Errorf("a vacation must end in the future")
+	case v.Start.After(now.Add(vacationHorizon)):
+		return fmt.Errorf("a vacation must start within a year")
+	}
+
+	if v.Start.Before(now) {
+		v.Start = now
+	}
+
+	for _, v2 := range vs {
+		if v.overlaps(v2) {
+			return fmt.Errorf("a vacation may not overlap another, from %s to %s",
+				v2.Start.Format(vacationDateForm), v2.End.Format(vacationDateForm))
+		}
+	}
+
+	// Check the periods ending with and beginning with the vacation.
+	all := append(vacations{v}, vs...)
+	if all.during(v.End.Add(-vacationPeriod), v.End) > maxVacationPerPeriod ||
+		all.during(v.Start, v.Start.Add(vacationPeriod)) > maxVacationPerPeriod {
+		return fmt.Errorf("vacations may total at most %d days a year", maxVacationPerPeriod/(24*time.Hour))
+	}
+	return nil
+}
+
+// deleteVacation deletes the vacation of the current user having the id given by the path.
+func deleteVacation(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	id, err := strconv.ParseInt(c.Param("vid"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid vacation %q", c.Param("vid"))})
+		return
+	}
+
+	v := &vacation{ID: id, Parent: datastore.KeyForObj(ctx, user.CurrentFrom(ctx))}
+	if err = datastore.Delete(ctx, v); err != nil {
+		log.Errorf(ctx, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to delete vacation"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"version": apiVersion})
+}
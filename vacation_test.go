@@ -0,0 +1,114 @@
+package got
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// day returns the time d days and h hours after the start of 2019-03-01.
+func day(d, h int) time.Time {
+	return time.Date(2019, time.March, 1+d, h, 0, 0, 0, time.UTC)
+}
+
+func vac(start, end time.Time) *vacation {
+	return &vacation{Start: start, End: end}
+}
+
+func TestPausedUntil(t *testing.T) {
+	tests := []struct {
+		name  string
+		vs    vacations
+		start time.Time
+		d     time.Duration
+		want  time.Time
+	}{
+		{"no vacations", nil, day(0, 0), 24 * time.Hour, day(1, 0)},
+		{"before the clock start", vacations{vac(day(-5, 0), day(-2, 0))}, day(0, 0), 24 * time.Hour, day(1, 0)},
+		{"ending at the clock start", vacations{vac(day(-2, 0), day(0, 0))}, day(0, 0), 24 * time.Hour, day(1, 0)},
+		{"after the deadline", vacations{vac(day(2, 0), day(4, 0))}, day(0, 0), 24 * time.Hour, day(1, 0)},
+		{"starting at the deadline", vacations{vac(day(1, 0), day(4, 0))}, day(0, 0), 24 * time.Hour, day(1, 0)},
+		{"within the allowance", vacations{vac(day(0, 12), day(2, 12))}, day(0, 0), 24 * time.Hour, day(3, 0)},
+		{"spanning the clock start", vacations{vac(day(-1, 0), day(1, 0))}, day(0, 0), 24 * time.Hour, day(2, 0)},
+		{"consecutive", vacations{vac(day(0, 6), day(1, 6)), vac(day(1, 6), day(2, 6))}, day(0, 0), 24 * time.Hour, day(3, 0)},
+		{"separate", vacations{vac(day(0, 6), day(1, 6)), vac(day(1, 12), day(2, 12))}, day(0, 0), 24 * time.Hour, day(3, 0)},
+		{"overlapping", vacations{vac(day(0, 6), day(1, 6)), vac(day(1, 0), day(2, 0))}, day(0, 0), 24 * time.Hour, day(2, 18)},
+		{"nested", vacations{vac(day(0, 6), day(3, 6)), vac(day(1, 0), day(2, 0))}, day(0, 0), 24 * time.Hour, day(4, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.vs.pausedUntil(tt.start, tt.d); !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActiveTime(t *testing.T) {
+	tests := []struct {
+		name       string
+		vs         vacations
+		start, end time.Time
+		want       time.Duration
+	}{
+		{"no vacations", nil, day(0, 0), day(1, 0), 24 * time.Hour},
+		{"before the clock start", vacations{vac(day(-5, 0), day(-2, 0))}, day(0, 0), day(1, 0), 24 * time.Hour},
+		{"after the end", vacations{vac(day(2, 0), day(4, 0))}, day(0, 0), day(1, 0), 24 * time.Hour},
+		{"within", vacations{vac(day(0, 6), day(0, 18))}, day(0, 0), day(1, 0), 12 * time.Hour},
+		{"spanning the clock start", vacations{vac(day(-1, 0), day(0, 6))}, day(0, 0), day(1, 0), 18 * time.Hour},
+		{"spanning the end", vacations{vac(day(0, 18), day(3, 0))}, day(0, 0), day(1, 0), 18 * time.Hour},
+		{"spanning the turn", vacations{vac(day(-1, 0), day(2, 0))}, day(0, 0), day(1, 0), 0},
+		{"overlapping", vacations{vac(day(0, 2), day(0, 10)), vac(day(0, 6), day(0, 14))}, day(0, 0), day(1, 0), 12 * time.Hour},
+		{"nested", vacations{vac(day(0, 2), day(0, 20)), vac(day(0, 6), day(0, 14))}, day(0, 0), day(1, 0), 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.vs.activeTime(tt.start, tt.end); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateVacation(t *testing.T) {
+	now := day(0, 15)
+	tests := []struct {
+		name      string
+		v         *vacation
+		vs        vacations
+		wantErr   string
+		wantStart time.Time
+	}{
+		{"future", vac(day(3, 0), day(10, 0)), nil, "", day(3, 0)},
+		{"starting today", vac(day(0, 0), day(5, 0)), nil, "", now},
+		{"starting yesterday", vac(day(-1, 0), day(5, 0)), nil, "past", time.Time{}},
+		{"ended", vac(day(-3, 0), day(-1, 0)), nil, "past", time.Time{}},
+		{"ending before it starts", vac(day(5, 0), day(5, 0)), nil, "end after", time.Time{}},
+		{"too long", vac(day(1, 0), day(32, 0)), nil, "at most 30 days", time.Time{}},
+		{"beyond the horizon", vac(day(400, 0), day(401, 0)), nil, "within a year", time.Time{}},
+		{"overlapping", vac(day(5, 0), day(10, 0)), vacations{vac(day(8, 0), day(12, 0))}, "overlap", time.Time{}},
+		{"within another", vac(day(5, 0), day(6, 0)), vacations{vac(day(4, 0), day(12, 0))}, "overlap", time.Time{}},
+		{"adjoining", vac(day(5, 0), day(10, 0)), vacations{vac(day(10, 0), day(12, 0))}, "", day(5, 0)},
+		{"chained", vac(day(31, 0), day(61, 0)), vacations{vac(day(1, 0), day(31, 0))}, "", day(31, 0)},
+		{"chained beyond the limit", vac(day(61, 0), day(62, 0)),
+			vacations{vac(day(1, 0), day(31, 0)), vac(day(31, 0), day(61, 0))}, "a year", time.Time{}},
+		{"before later vacations beyond the limit", vac(day(1, 0), day(2, 0)),
+			vacations{vac(day(100, 0), day(130, 0)), vac(day(200, 0), day(230, 0))}, "a year", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateVacation(tt.v, tt.vs, now)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("got error %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("got error %v, want error containing %q", err, tt.wantErr)
+			case err == nil && !tt.v.Start.Equal(tt.wantStart):
+				t.Errorf("the vacation starts %v, want %v", tt.v.Start, tt.wantStart)
+			}
+		})
+	}
+}
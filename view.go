@@ -0,0 +1,178 @@
+package got
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/user"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/context"
+)
+
+const jsonExt = ".json"
+
+// gameView is the JSON representation of a game as seen by a particular viewer.
+// Full indicates that nothing has been redacted.
+type gameView struct {
+	ID              int64         `json:"id"`
+	Title           string        `json:"title"`
+	Phase           string        `json:"phase"`
+	Turn            int           `json:"turn"`
+	Round           int           `json:"round"`
+	TwoThiefVariant bool          `json:"twoThiefVariant"`
+	Ruleset         string        `json:"ruleset"`
+	Teams           [][]int       `json:"teams,omitempty"`
+	Scenario        string        `json:"scenario,omitempty"`
+	Goal            string        `json:"goal,omitempty"`
+	Deadline        *time.Time    `json:"deadline,omitempty"`
+	CurrentPlayerID int           `json:"currentPlayerId"`
+	Jewels          cardView      `json:"jewels"`
+	Grid            [][]areaView  `json:"grid"`
+	Players         []*playerView `json:"players"`
+	Full            bool          `json:"full"`
+}
+
+// playerView is the JSON representation of a player as seen by a particular viewer.
+// Hand, DrawPile and DiscardPile are omitted when hidden from the viewer.
+type playerView struct {
+	ID              int        `json:"id"`
+	Name            string     `json:"name"`
+	Score           int        `json:"score"`
+	Passed          bool       `json:"passed"`
+	HandSize        int        `json:"handSize"`
+	FaceUp          []cardView `json:"faceUp"`
+	Hand            []cardView `json:"hand,omitempty"`
+	DrawPileSize    int        `json:"drawPileSize"`
+	DrawPile        []cardView `json:"drawPile,omitempty"`
+	DiscardPileSize int        `json:"discardPileSize"`
+	DiscardPile     []cardView `json:"discardPile,omitempty"`
+	OnVacation      bool       `json:"onVacation,omitempty"`
+}
+
+type areaView struct {
+	Row     string    `json:"row"`
+	Column  string    `json:"column"`
+	Thief   int       `json:"thief"`
+	Card    *cardView `json:"card,omitempty"`
+	Blocked bool      `json:"blocked,omitempty"`
+}
+
+type cardView struct {
+	Type   string `json:"type"`
+	FaceUp bool   `json:"faceUp"`
+}
+
+func newCardView(c *Card) cardView {
+	return cardView{Type: c.IDString(), FaceUp: c.FaceUp}
+}
+
+func cardViews(cs Cards) []cardView {
+	vs := make([]cardView, len(cs))
+	for i, c := range cs {
+		vs[i] = newCardView(c)
+	}
+	return vs
+}
+
+// viewFor returns the game as seen by the current user.
+// Admins and, once the game is over, all users see the full game.
+func (g *Game) viewFor(ctx context.Context) *gameView {
+	full := user.IsAdmin(ctx) || g.Phase == gameOver
+
+	v := &gameView{
+		ID:              g.ID,
+		Title:           g.Title,
+		Phase:           g.PhaseName(),
+		Turn:            g.Turn,
+		Round:           g.Round,
+		TwoThiefVariant: g.TwoThiefVariant,
+		Ruleset:         g.ruleset().Name,
+		Teams:           g.Teams,
+		CurrentPlayerID: noPID,
+		Jewels:          newCardView(&g.Jewels),
+		Full:            full,
+	}
+
+	if cp := g.CurrentPlayer(); cp != nil {
+		v.CurrentPlayerID = cp.ID()
+	}
+
+	if d, ok := g.deadline(ctx); ok {
+		v.Deadline = &d
+	}
+
+	if g.isSolo() {
+		v.Scenario = g.Scenario.Name
+		v.Goal = g.Scenario.Goal.String()
+	}
+
+	v.Grid = make([][]areaView, len(g.Grid))
+	for row, as := range g.Grid {
+		v.Grid[row] = make([]areaView, len(as))
+		for col, a := range as {
+			av := areaView{Row: a.RowString(), Column: a.ColString(), Thief: a.Thief, Blocked: a.Blocked}
+			if a.Card != nil {
+				cv := newCardView(a.Card)
+				av.Card = &cv
+			}
+			v.Grid[row][col] = av
+		}
+	}
+
+	now := time.Now()
+	for _, p := range g.Players() {
+		pv := &playerView{
+			ID:              p.ID(),
+			Name:            g.NameFor(p),
+			Score:           p.Score,
+			Passed:          p.Passed,
+			HandSize:        len(p.Hand),
+			DrawPileSize:    len(p.DrawPile),
+			DiscardPileSize: len(p.DiscardPile),
+			OnVacation:      g.onVacation(ctx, p, now),
+		}
+
+		for _, c := range p.Hand {
+			if c.FaceUp {
+				pv.FaceUp = append(pv.FaceUp, newCardView(c))
+			}
+		}
+
+		if full || p.IsCurrentUser(ctx) {
+			pv.Hand = cardViews(p.Hand)
+			pv.DiscardPile = cardViews(p.DiscardPile)
+		}
+
+		if full {
+			pv.DrawPile = cardViews(p.DrawPile)
+		}
+		v.Players = append(v.Players, pv)
+	}
+	return v
+}
+
+// isJSONRequest indicates whether the request asks for the JSON representation of the game.
+func isJSONRequest(c *gin.Context) bool {
+	return strings.HasSuffix(c.Param("hid"), jsonExt)
+}
+
+// hidFrom returns the game id parameter without any format extension.
+func hidFrom(c *gin.Context) string {
+	return strings.TrimSuffix(c.Param("hid"), jsonExt)
+}
+
+func showJSON(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	g := gameFrom(ctx)
+	if g == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "game not found"})
+		return
+	}
+	c.JSON(http.StatusOK, g.viewFor(ctx))
+}
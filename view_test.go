@@ -0,0 +1,104 @@
+package got
+
+import (
+	"reflect"
+	"testing"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/game"
+	"github.com/SlothNinja/gt/engine"
+	"golang.org/x/net/context"
+)
+
+// testViewGame returns a running game of three players, played until a player has cards in the draw pile.
+// The players are bots, so that the viewer controls none of them.
+func testViewGame(t *testing.T) *Game {
+	t.Helper()
+
+	g := &Game{Header: &game.Header{ID: 1, Title: "view", NumPlayers: 3, Status: game.Running}, State: newState()}
+	for i := 0; i < g.NumPlayers; i++ {
+		p := newPlayer()
+		p.SetID(i)
+		p.Bot = randomBot
+		g.Playerers = append(g.Playerers, p)
+	}
+
+	s := engine.New([]int{0, 1, 2}, false, 1)
+	r := engine.NewRand(2)
+	for !hasDrawPile(s) {
+		if s.Phase == engine.PhaseGameOver {
+			t.Fatal("no player drew from a draw pile")
+		}
+
+		m := engine.RandomStrategy{}.Choose(s, s.CurrentPlayerID, engine.LegalActions(s, s.CurrentPlayerID), &r)
+		var err error
+		if s, err = engine.Play(s, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g.setEngineState(s)
+	return g
+}
+
+func hasDrawPile(s *engine.State) bool {
+	for _, p := range s.Players {
+		if len(p.DrawPile) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func TestViewForRedacts(t *testing.T) {
+	g := testViewGame(t)
+	v := g.viewFor(context.Background())
+	if v.Full {
+		t.Fatal("a viewer playing no part in a running game sees the full game")
+	}
+
+	for i, p := range g.Players() {
+		pv := v.Players[i]
+		switch {
+		case pv.Hand != nil:
+			t.Errorf("the hand of player %d is shown", p.ID())
+		case pv.DrawPile != nil:
+			t.Errorf("the draw pile of player %d is shown", p.ID())
+		case pv.DiscardPile != nil:
+			t.Errorf("the discard pile of player %d is shown", p.ID())
+		}
+
+		if pv.HandSize != len(p.Hand) || pv.DrawPileSize != len(p.DrawPile) || pv.DiscardPileSize != len(p.DiscardPile) {
+			t.Errorf("player %d has sizes %d, %d and %d, want %d, %d and %d", p.ID(),
+				pv.HandSize, pv.DrawPileSize, pv.DiscardPileSize, len(p.Hand), len(p.DrawPile), len(p.DiscardPile))
+		}
+
+		var faceUp []cardView
+		for _, c := range p.Hand {
+			if c.FaceUp {
+				faceUp = append(faceUp, newCardView(c))
+			}
+		}
+		if !reflect.DeepEqual(pv.FaceUp, faceUp) {
+			t.Errorf("player %d shows face up cards %v, want %v", p.ID(), pv.FaceUp, faceUp)
+		}
+	}
+}
+
+func TestViewForGameOver(t *testing.T) {
+	g := testViewGame(t)
+	g.Phase = gameOver
+	v := g.viewFor(context.Background())
+	if !v.Full {
+		t.Fatal("the game over is not shown in full")
+	}
+
+	for i, p := range g.Players() {
+		pv := v.Players[i]
+		if !reflect.DeepEqual(pv.Hand, cardViews(p.Hand)) {
+			t.Errorf("player %d shows hand %v, want %v", p.ID(), pv.Hand, cardViews(p.Hand))
+		}
+		// The draw pile is shown in order once nothing more may be drawn.
+		if !reflect.DeepEqual(pv.DrawPile, cardViews(p.DrawPile)) {
+			t.Errorf("player %d shows draw pile %v, want %v", p.ID(), pv.DrawPile, cardViews(p.DrawPile))
+		}
+	}
+}
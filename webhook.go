@@ -0,0 +1,497 @@
+package got
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"bitbucket.org/SlothNinja/slothninja-games/sn/log"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/restful"
+	"bitbucket.org/SlothNinja/slothninja-games/sn/user"
+	"github.com/gin-gonic/gin"
+	"go.chromium.org/gae/service/datastore"
+	"go.chromium.org/gae/service/urlfetch"
+	"golang.org/x/net/context"
+)
+
+// Game events to which webhooks may subscribe.
+const (
+	gameCreated    = "game.created"
+	playerAccepted = "player.accepted"
+	playerDropped  = "player.dropped"
+	gameStarted    = "game.started"
+	turnChanged    = "turn.changed"
+	gameEnded      = "game.ended"
+)
+
+var webhookEvents = []string{gameCreated, playerAccepted, playerDropped, gameStarted, turnChanged, gameEnded}
+
+// Headers of webhook requests.  The signature is the hex encoded HMAC-SHA256 of the body, keyed by
+// the secret of the webhook, prefixed by "sha256=".
+const (
+	webhookEventHeader     = "X-GOT-Event"
+	webhookDeliveryHeader  = "X-GOT-Delivery"
+	webhookSignatureHeader = "X-GOT-Signature"
+)
+
+// Limits of webhook deliveries.  Deliveries are first attempted by the next run of retryWebhooks.
+// A failed delivery is retried after a minute, and after doubling intervals thereafter, until
+// maxWebhookAttempts attempts have been made.
+const (
+	webhookTimeout     = 10 * time.Second
+	webhookRetry       = time.Minute
+	maxWebhookAttempts = 6
+	maxDeliveriesShown = 100
+)
+
+// webhook subscribes a URL to the events of a game or, without a game, to the events of the games
+// of the user within whose key it is kept.
+type webhook struct {
+	Kind      string         `gae:"$kind,GOTWebhook"`
+	ID        int64          `gae:"$id"`
+	Parent    *datastore.Key `gae:"$parent"`
+	GameID    int64
+	URL       string `gae:",noindex"`
+	Secret    string `gae:",noindex"`
+	Events    []string
+	CreatedAt time.Time
+}
+
+// subscribes indicates whether the webhook subscribes to event.  A webhook listing no events subscribes to all.
+func (h *webhook) subscribes(event string) bool {
+	if len(h.Events) == 0 {
+		return true
+	}
+	return includesString(h.Events, event)
+}
+
+func includesString(ss []string, s string) bool {
+	for _, s2 := range ss {
+		if s2 == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sign returns the signature of body.
+func (h *webhook) sign(body []byte) string {
+	m := hmac.New(sha256.New, []byte(h.Secret))
+	m.Write(body)
+	return "sha256=" + hex.EncodeToString(m.Sum(nil))
+}
+
+// gameEvent is the JSON payload posted to webhooks.
+type gameEvent struct {
+	Event   string    `json:"event"`
+	GameID  int64     `json:"gameId"`
+	Title   string    `json:"title"`
+	Time    time.Time `json:"time"`
+	Players []string  `json:"players,omitempty"`
+	// Player names the player accepted, dropped or whose turn began.
+	Player  string   `json:"player,omitempty"`
+	Winners []string `json:"winners,omitempty"`
+	Results results  `json:"results,omitempty"`
+
+	// user is the user accepted or dropped, whose webhooks receive the event though the user
+	// may no longer be in the game.
+	user *user.User
+}
+
+// queueEvent queues event for delivery to the webhooks of the game once the game is stored.
+func (g *Game) queueEvent(e *gameEvent) {
+	e.Time = time.Now()
+	g.events = append(g.events, e)
+}
+
+// userEvent returns an event concerning user u, such as the user's acceptance of a place in the game.
+func userEvent(event string, u *user.User) *gameEvent {
+	return &gameEvent{Event: event, Player: u.Name, user: u}
+}
+
+// endedEvent returns the event announcing the end of the game with results rs.
+func (g *Game) endedEvent(rs results) *gameEvent {
+	var names []string
+	for _, p := range g.winners() {
+		names = append(names, g.NameFor(p))
+	}
+	return &gameEvent{Event: gameEnded, Winners: names, Results: rs}
+}
+
+// webhooksFor returns the webhooks subscribing to event e of the game: those of the game and
+// those of the users of the game.
+func (g *Game) webhooksFor(ctx context.Context, e *gameEvent) ([]*webhook, error) {
+	var hs []*webhook
+	q := datastore.NewQuery("GOTWebhook").Eq("GameID", g.ID)
+	if err := datastore.GetAll(ctx, q, &hs); err != nil {
+		return nil, err
+	}
+
+	us := g.Users
+	if e.user != nil {
+		us = append(user.Users{e.user}, us...)
+	}
+
+	seen := make(map[int64]bool)
+	for _, u := range us {
+		if u == nil || seen[u.ID] {
+			continue
+		}
+		seen[u.ID] = true
+
+		var uhs []*webhook
+		q := datastore.NewQuery("GOTWebhook").Ancestor(datastore.KeyForObj(ctx, u)).Eq("GameID", 0)
+		if err := datastore.GetAll(ctx, q, &uhs); err != nil {
+			return nil, err
+		}
+		hs = append(hs, uhs...)
+	}
+
+	subscribed := hs[:0]
+	for _, h := range hs {
+		if h.subscribes(e.Event) {
+			subscribed = append(subscribed, h)
+		}
+	}
+	return subscribed, nil
+}
+
+// delivery records the delivery of an event to a webhook.
+type delivery struct {
+	Kind    string `gae:"$kind,GOTWebhookDelivery"`
+	ID      int64  `gae:"$id"`
+	Webhook *datastore.Key
+	GameID  int64
+	Event   string
+	Payload []byte `gae:",noindex"`
+	// Status is the HTTP status of the last response, or zero if none was received.
+	Status   int
+	Error    string `gae:",noindex"`
+	Attempts int
+	// Pending indicates whether the delivery is to be attempted at NextAttempt.
+	Pending     bool
+	Delivered   bool
+	NextAttempt time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// attempt posts the payload of the delivery to webhook h, and records the outcome.
+func (d *delivery) attempt(ctx context.Context, h *webhook, now time.Time) {
+	d.Attempts++
+	d.UpdatedAt = now
+	d.Status = 0
+
+	err := d.post(ctx, h)
+	switch {
+	case err == nil:
+		d.Delivered, d.Pending, d.Error = true, false, ""
+	case d.Attempts >= maxWebhookAttempts:
+		d.Pending, d.Error = false, err.Error()
+	default:
+		d.Error = err.Error()
+		d.NextAttempt = now.Add(webhookRetry << uint(d.Attempts-1))
+	}
+}
+
+func (d *delivery) post(ctx context.Context, h *webhook) error {
+	req, err := http.NewRequest(http.MethodPost, h.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, d.Event)
+	req.Header.Set(webhookDeliveryHeader, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(webhookSignatureHeader, h.sign(d.Payload))
+
+	client := &http.Client{Transport: urlfetch.Get(ctx), Timeout: webhookTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if d.Status = resp.StatusCode; d.Status < 200 || d.Status > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+// deliverEvents records the deliveries of the events queued since the game was last stored to the webhooks
+// subscribing to them.  The deliveries are attempted by retryWebhooks, so that no request awaits a webhook.
+func (g *Game) deliverEvents(ctx context.Context) {
+	es := g.events
+	g.events = nil
+
+	for _, e := range es {
+		e.GameID, e.Title = g.ID, g.Title
+		e.Players = g.UserNames
+		if ps := g.Players(); len(ps) > 0 {
+			e.Players = make([]string, len(ps))
+			for i, p := range ps {
+				e.Players[i] = g.NameFor(p)
+			}
+		}
+
+		if err := g.deliverEvent(ctx, e); err != nil {
+			log.Warningf(ctx, "unable to deliver %s event of game %d: %v", e.Event, g.ID, err)
+		}
+	}
+}
+
+func (g *Game) deliverEvent(ctx context.Context, e *gameEvent) error {
+	hs, err := g.webhooksFor(ctx, e)
+	if err != nil || len(hs) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return queueDeliveries(ctx, hs, g.ID, e.Event, payload)
+}
+
+// queueDeliveries stores a pending delivery of payload, the JSON encoding of event concerning the game
+// identified by gid, to each of webhooks hs.  A gid of zero indicates the event concerns no one game.
+func queueDeliveries(ctx context.Context, hs []*webhook, gid int64, event string, payload []byte) error {
+	now := time.Now()
+	ds := make([]*delivery, len(hs))
+	for i, h := range hs {
+		ds[i] = &delivery{
+			Webhook:     datastore.KeyForObj(ctx, h),
+			GameID:      gid,
+			Event:       event,
+			Payload:     payload,
+			Pending:     true,
+			NextAttempt: now,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+	}
+
+	return datastore.Put(ctx, ds)
+}
+
+// retryWebhooks attempts the pending deliveries of events that are due, both new deliveries and
+// failed deliveries due another attempt.  It is requested periodically by cron, and may also be requested by admins.
+func retryWebhooks(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	if !cronOrAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only cron and admins may retry webhooks."})
+		return
+	}
+
+	now := time.Now()
+	var ds []*delivery
+	q := datastore.NewQuery("GOTWebhookDelivery").Eq("Pending", true).Lte("NextAttempt", now)
+	if err := datastore.GetAll(ctx, q, &ds); err != nil {
+		log.Errorf(ctx, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to find pending deliveries"})
+		return
+	}
+
+	retried := make([]int64, 0)
+	for _, d := range ds {
+		h := new(webhook)
+		if !datastore.PopulateKey(h, d.Webhook) {
+			continue
+		}
+
+		switch err := datastore.Get(ctx, h); {
+		case err == datastore.ErrNoSuchEntity:
+			// The webhook was deleted.
+			d.Pending, d.Error = false, "webhook deleted"
+		case err != nil:
+			log.Warningf(ctx, "unable to get webhook for delivery %d: %v", d.ID, err)
+			continue
+		default:
+			d.attempt(ctx, h, now)
+		}
+
+		if err := datastore.Put(ctx, d); err != nil {
+			log.Warningf(ctx, "unable to update delivery %d: %v", d.ID, err)
+			continue
+		}
+		retried = append(retried, d.ID)
+	}
+	c.JSON(http.StatusOK, gin.H{"version": apiVersion, "retried": retried})
+}
+
+// webhookView is the JSON representation of a webhook.  The secret is shown only when the webhook is added.
+type webhookView struct {
+	ID        int64     `json:"id"`
+	GameID    int64     `json:"gameId,omitempty"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (h *webhook) view() webhookView {
+	return webhookView{ID: h.ID, GameID: h.GameID, URL: h.URL, Events: h.Events, CreatedAt: h.CreatedAt}
+}
+
+// showWebhooks responds with the webhooks of the current user.
+func showWebhooks(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	var hs []*webhook
+	q := datastore.NewQuery("GOTWebhook").Ancestor(datastore.KeyForObj(ctx, user.CurrentFrom(ctx)))
+	if err := datastore.GetAll(ctx, q, &hs); err != nil {
+		log.Errorf(ctx, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to get webhooks"})
+		return
+	}
+
+	views := make([]webhookView, len(hs))
+	for i, h := range hs {
+		views[i] = h.view()
+	}
+	c.JSON(http.StatusOK, gin.H{"version": apiVersion, "webhooks": views, "events": webhookEvents})
+}
+
+// addWebhook adds a webhook of the current user posting to the form's url.  The form may give
+// the game whose events are posted, otherwise the events of the user's games are posted, and the events
+// posted, otherwise all events are posted.  The response gives the secret with which payloads are signed.
+func addWebhook(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	h, err := webhookFromForm(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	secret := make([]byte, 32)
+	if _, err = rand.Read(secret); err != nil {
+		log.Errorf(ctx, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to generate secret"})
+		return
+	}
+
+	h.Parent = datastore.KeyForObj(ctx, user.CurrentFrom(ctx))
+	h.Secret = hex.EncodeToString(secret)
+	h.CreatedAt = time.Now()
+	if err = datastore.Put(ctx, h); err != nil {
+		log.Errorf(ctx, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to save webhook"})
+		return
+	}
+
+	v := h.view()
+	v.Secret = h.Secret
+	c.JSON(http.StatusOK, gin.H{"version": apiVersion, "webhook": v})
+}
+
+func webhookFromForm(c *gin.Context) (*webhook, error) {
+	h := new(webhook)
+
+	u, err := url.Parse(c.PostForm("url"))
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("invalid url %q", c.PostForm("url"))
+	case (u.Scheme != "https" && u.Scheme != "http") || u.Host == "":
+		return nil, fmt.Errorf("a webhook url must be an absolute http or https url, but is %q", c.PostForm("url"))
+	}
+	h.URL = u.String()
+
+	if id := c.PostForm("game"); id != "" {
+		if h.GameID, err = strconv.ParseInt(id, 10, 64); err != nil || h.GameID < 1 {
+			return nil, fmt.Errorf("invalid game %q", id)
+		}
+	}
+
+	for _, event := range c.PostFormArray("events") {
+		if !includesString(webhookEvents, event) {
+			return nil, fmt.Errorf("unknown event %q", event)
+		}
+		h.Events = append(h.Events, event)
+	}
+	return h, nil
+}
+
+// deleteWebhook deletes the webhook of the current user having the id given by the path.
+func deleteWebhook(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	id, err := strconv.ParseInt(c.Param("wid"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid webhook %q", c.Param("wid"))})
+		return
+	}
+
+	h := &webhook{ID: id, Parent: datastore.KeyForObj(ctx, user.CurrentFrom(ctx))}
+	if err = datastore.Delete(ctx, h); err != nil {
+		log.Errorf(ctx, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to delete webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"version": apiVersion})
+}
+
+// webhookDeliveries responds with the latest deliveries of events to webhooks, optionally those of the game
+// given by the query.
+func webhookDeliveries(c *gin.Context) {
+	ctx := restful.ContextFrom(c)
+	log.Debugf(ctx, "Entering")
+	defer log.Debugf(ctx, "Exiting")
+
+	if !user.IsAdmin(ctx) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins may view webhook deliveries."})
+		return
+	}
+
+	q := datastore.NewQuery("GOTWebhookDelivery").Order("-CreatedAt").Limit(maxDeliveriesShown)
+	if id := c.Query("game"); id != "" {
+		gid, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid game %q", id)})
+			return
+		}
+		q = q.Eq("GameID", gid)
+	}
+
+	var ds []*delivery
+	if err := datastore.GetAll(ctx, q, &ds); err != nil {
+		log.Errorf(ctx, err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "unable to get deliveries"})
+		return
+	}
+
+	views := make([]gin.H, len(ds))
+	for i, d := range ds {
+		views[i] = gin.H{
+			"id":          d.ID,
+			"webhook":     d.Webhook.IntID(),
+			"gameId":      d.GameID,
+			"event":       d.Event,
+			"status":      d.Status,
+			"error":       d.Error,
+			"attempts":    d.Attempts,
+			"pending":     d.Pending,
+			"delivered":   d.Delivered,
+			"nextAttempt": d.NextAttempt,
+			"createdAt":   d.CreatedAt,
+			"updatedAt":   d.UpdatedAt,
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"version": apiVersion, "deliveries": views})
+}